@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+)
+
+// templateArgsFileNames are the args files of template, the first found is
+// used
+var templateArgsFileNames = []string{"args.json", "args.yaml", "args.yml", "args.toml"}
+
+// unmarshalArgs decodes the args file by its extension, json, yaml or toml
+func unmarshalArgs(filename string, data []byte, args *map[string]interface{}) error {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, args)
+	case ".toml":
+		_, err := toml.Decode(string(data), args)
+		return err
+	}
+	return json.Unmarshal(data, args)
+}
+
+// templateArgs merges the args passed into template, precedence from low to
+// high: template args.json (or args.yaml, args.toml), createOpts.ArgsFiles in order, the args passed
+// into CreateProject, the environment variables with createOpts.ArgsEnvPrefix
+func (p *SpiritHelper) templateArgs(createOpts CreateOptions, tmplArgs map[string]interface{}) (args map[string]interface{}, err error) {
+	args = map[string]interface{}{}
+
+	for _, name := range templateArgsFileNames {
+		tmplArgsPath := path.Join(createOpts.templateDir(), name)
+
+		argData, e := ioutil.ReadFile(tmplArgsPath)
+		if os.IsNotExist(e) {
+			continue
+		} else if e != nil {
+			err = fmt.Errorf("read args file %s of template %s failed, %s", tmplArgsPath, createOpts.TemplateName, e)
+			return
+		}
+
+		p.logger().Infof("using template args of %s: %s", createOpts.TemplateName, tmplArgsPath)
+
+		if err = unmarshalArgs(tmplArgsPath, argData, &args); err != nil {
+			err = fmt.Errorf("parse args file %s of template %s failed, the file is shipped with template, not the config or -a args, %s", tmplArgsPath, createOpts.TemplateName, err)
+			return
+		}
+
+		break
+	}
+
+	for _, argsFile := range createOpts.ArgsFiles {
+		var argData []byte
+		if argData, err = ioutil.ReadFile(argsFile); err != nil {
+			err = fmt.Errorf("read args file %s failed, %s", argsFile, err)
+			return
+		}
+
+		fileArgs := map[string]interface{}{}
+		if err = unmarshalArgs(argsFile, argData, &fileArgs); err != nil {
+			err = fmt.Errorf("parse args file %s failed, %s", argsFile, err)
+			return
+		}
+
+		for k, v := range fileArgs {
+			args[k] = v
+		}
+	}
+
+	for k, v := range tmplArgs {
+		args[k] = v
+	}
+
+	if createOpts.ArgsEnvPrefix != "" {
+		for k, v := range envArgs(createOpts.ArgsEnvPrefix) {
+			args[k] = v
+		}
+	}
+
+	return
+}
+
+// completeArgs checks the args referenced by template are all given, the
+// missing args are resolved by createOpts.LazyArgs, then read from stdin in
+// interactive mode, otherwise it returns error listing them
+func (p *SpiritHelper) completeArgs(createOpts CreateOptions, tmpl *template.Template, args map[string]interface{}) (err error) {
+	var missing []string
+	for _, key := range templateArgKeys(tmpl) {
+		if v, exist := args[key]; exist && v != nil {
+			continue
+		}
+
+		if resolve, exist := createOpts.LazyArgs[key]; exist {
+			var v interface{}
+			if v, err = resolve(); err != nil {
+				err = fmt.Errorf("resolve arg %s of template %s failed, %s", key, createOpts.TemplateName, err)
+				return
+			}
+
+			if v != nil {
+				args[key] = v
+				continue
+			}
+		}
+
+		missing = append(missing, key)
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	if !createOpts.Interactive {
+		err = fmt.Errorf("template %s requires args: %s, set them by -a key=val", createOpts.TemplateName, strings.Join(missing, ", "))
+		return
+	}
+
+	var stdin = p.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	reader := bufio.NewReader(stdin)
+
+	for _, key := range missing {
+		fmt.Fprintf(os.Stderr, "template %s requires arg %s: ", createOpts.TemplateName, key)
+
+		var line string
+		if line, err = reader.ReadString('\n'); err != nil && line == "" {
+			err = fmt.Errorf("read arg %s failed, %s", key, err)
+			return
+		}
+		err = nil
+
+		args[key] = strings.TrimSpace(line)
+	}
+
+	return
+}
+
+// templateArgKeys returns the keys of args referenced by `.args.key` in tmpl
+func templateArgKeys(tmpl *template.Template) (keys []string) {
+	found := map[string]bool{}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walkArgKeys(t.Tree.Root, found)
+		}
+	}
+
+	for key := range found {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return
+}
+
+func walkArgKeys(node parse.Node, found map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkArgKeys(child, found)
+		}
+	case *parse.ActionNode:
+		walkArgKeys(n.Pipe, found)
+	case *parse.IfNode:
+		walkArgKeys(&n.BranchNode, found)
+	case *parse.RangeNode:
+		walkArgKeys(&n.BranchNode, found)
+	case *parse.WithNode:
+		walkArgKeys(&n.BranchNode, found)
+	case *parse.BranchNode:
+		walkArgKeys(n.Pipe, found)
+		walkArgKeys(n.List, found)
+		walkArgKeys(n.ElseList, found)
+	case *parse.TemplateNode:
+		walkArgKeys(n.Pipe, found)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkArgKeys(cmd, found)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkArgKeys(arg, found)
+		}
+	case *parse.ChainNode:
+		walkArgKeys(n.Node, found)
+	case *parse.FieldNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "args" {
+			found[n.Ident[1]] = true
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func parseTestTemplate(t *testing.T, text string) *template.Template {
+	tmpl, err := template.New("test").Funcs(templateFuncs).Delims(defaultLeftDelim, defaultRightDelim).Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
+func TestTemplateArgKeys(t *testing.T) {
+	tmpl := parseTestTemplate(t, `
+//<-.args.a->//
+//<-if .args.b->//x//<-else->////<-.args.c->////<-end->//
+//<-range $_, $pkg := .packages->////<-$pkg.URI->////<-end->//
+//<-with .args.d->//x//<-end->//
+//<-quoteGo .args.e | printf "%s"->//
+//<-define "sub"->////<-.args.f->////<-end->//
+//<-template "sub" .->//
+//<-.create_time->//
+`)
+
+	expected := []string{"a", "b", "c", "d", "e", "f"}
+	if keys := templateArgKeys(tmpl); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("arg keys are %v, want %v", keys, expected)
+	}
+}
+
+func TestCompleteArgs(t *testing.T) {
+	tmpl := parseTestTemplate(t, `//<-.args.given->// //<-.args.lazy->// //<-.args.missing->// //<-.args.nil->//`)
+
+	lazyCalls := 0
+	createOpts := CreateOptions{
+		TemplateName: "test",
+		LazyArgs: map[string]func() (interface{}, error){
+			"given": func() (interface{}, error) {
+				t.Errorf("lazy arg of the given key is called")
+				return nil, nil
+			},
+			"lazy": func() (interface{}, error) {
+				lazyCalls++
+				return "resolved", nil
+			},
+			"nil": func() (interface{}, error) {
+				return nil, nil
+			},
+		},
+	}
+
+	helper := &SpiritHelper{}
+
+	args := map[string]interface{}{"given": "value"}
+
+	err := helper.completeArgs(createOpts, tmpl, args)
+	if err == nil || !strings.Contains(err.Error(), "missing, nil") {
+		t.Errorf("the missing args are not reported: %v", err)
+	}
+
+	if args["lazy"] != "resolved" || lazyCalls != 1 {
+		t.Errorf("lazy arg is %v after %d calls, want resolved once", args["lazy"], lazyCalls)
+	}
+
+	createOpts.Interactive = true
+	helper.Stdin = strings.NewReader("typed\n typed nil \n")
+
+	if err = helper.completeArgs(createOpts, tmpl, args); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"given":   "value",
+		"lazy":    "resolved",
+		"missing": "typed",
+		"nil":     "typed nil",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("completed args are %v, want %v", args, expected)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path"
+)
+
+// Artifact is a file copied after the project is built, the relative Src
+// and Dst are under the project dir
+type Artifact struct {
+	Src string
+	Dst string
+}
+
+// copyArtifacts copies the artifacts of createOpts, the dirs of destination
+// are created as needed
+func (p *SpiritHelper) copyArtifacts(createOpts CreateOptions) (err error) {
+	projectPath := createOpts.projectDir()
+
+	for _, artifact := range createOpts.Artifacts {
+		src, dst := artifact.Src, artifact.Dst
+		if !path.IsAbs(src) {
+			src = path.Join(projectPath, src)
+		}
+		if !path.IsAbs(dst) {
+			dst = path.Join(projectPath, dst)
+		}
+
+		if err = copyFile(src, dst); err != nil {
+			return
+		}
+
+		p.logger().Infof("artifact %s copied to %s", src, dst)
+	}
+
+	return
+}
+
+// copyFile copies src to dst with the mode of src
+func copyFile(src, dst string) (err error) {
+	var fi os.FileInfo
+	if fi, err = os.Stat(src); err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(path.Dir(dst), os.FileMode(0755)); err != nil {
+		return
+	}
+
+	var in *os.File
+	if in, err = os.Open(src); err != nil {
+		return
+	}
+	defer in.Close()
+
+	var out *os.File
+	if out, err = os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode()); err != nil {
+		return
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ProjectSpec describes a project created by GenerateAll
+type ProjectSpec struct {
+	Config   string                 `json:"config"`
+	Template string                 `json:"template"`
+	Path     string                 `json:"path"`
+	Args     map[string]interface{} `json:"args"`
+}
+
+type GenerateResult struct {
+	Spec ProjectSpec
+	Err  error
+}
+
+// FetchSummary reports the packages fetched by GenerateAll, Skipped counts
+// the fetches saved as the package was already fetched by another project
+type FetchSummary struct {
+	Packages []string
+	Skipped  int
+}
+
+// fetchRecord is shared by the projects of GenerateAll, it is guarded by
+// fetchMutex
+type fetchRecord struct {
+	packages map[string]string
+	skipped  int
+}
+
+// GenerateAll creates the projects of specs based on baseOpts, at most
+// concurrency projects are created at the same time. The failure of one
+// project does not abort the others, the results are in the order of specs
+// and err summarizes the failed projects. The projects share the packages in
+// GOPATH, so they are fetched by one project at a time
+func (p *SpiritHelper) GenerateAll(baseOpts CreateOptions, specs []ProjectSpec, concurrency int) (results []GenerateResult, err error) {
+	results, _, err = p.GenerateAllContext(context.Background(), baseOpts, specs, concurrency)
+	return
+}
+
+// GenerateAllContext is GenerateAll which stops when ctx is done, the pending
+// projects are not started, the fetching of in-flight projects is killed and
+// their project dirs are removed if they are created by them. A package is
+// fetched once for all projects with the same revision
+func (p *SpiritHelper) GenerateAllContext(ctx context.Context, baseOpts CreateOptions, specs []ProjectSpec, concurrency int) (results []GenerateResult, summary FetchSummary, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results = make([]GenerateResult, len(specs))
+
+	fetchMutex := &sync.Mutex{}
+	fetched := &fetchRecord{packages: map[string]string{}}
+	tokens := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for i, spec := range specs {
+		select {
+		case <-ctx.Done():
+			results[i] = GenerateResult{Spec: spec, Err: ctx.Err()}
+			continue
+		case tokens <- struct{}{}:
+		}
+
+		wg.Add(1)
+
+		go func(i int, spec ProjectSpec) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+
+			helper := p.clone()
+			helper.ctx = ctx
+			helper.fetchMutex = fetchMutex
+			helper.fetched = fetched
+
+			results[i] = GenerateResult{Spec: spec, Err: helper.generate(baseOpts, spec)}
+		}(i, spec)
+	}
+
+	wg.Wait()
+
+	for uri := range fetched.packages {
+		summary.Packages = append(summary.Packages, uri)
+	}
+	sort.Strings(summary.Packages)
+	summary.Skipped = fetched.skipped
+
+	p.logger().Infof("%d packages fetched, %d fetches skipped as shared", len(summary.Packages), summary.Skipped)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			p.logger().Errorf("project %s failed: %s", result.Spec.Path, result.Err)
+		} else {
+			p.logger().Infof("project %s created", result.Spec.Path)
+		}
+	}
+
+	if failed > 0 {
+		err = fmt.Errorf("%d of %d projects failed", failed, len(specs))
+		return
+	}
+
+	return
+}
+
+func (p *SpiritHelper) generate(baseOpts CreateOptions, spec ProjectSpec) (err error) {
+	if err = p.LoadSpiritConfig(spec.Config); err != nil {
+		return
+	}
+
+	createOpts := baseOpts
+	createOpts.ProjectPath = spec.Path
+	if spec.Template != "" {
+		createOpts.TemplateName = spec.Template
+	}
+
+	projectPath := createOpts.projectDir()
+	_, statErr := os.Stat(projectPath)
+
+	if err = p.CreateProject(createOpts, spec.Args); err != nil {
+		if p.ctx != nil && p.ctx.Err() != nil {
+			if os.IsNotExist(statErr) {
+				os.RemoveAll(projectPath)
+			}
+			err = fmt.Errorf("%s, %s", p.ctx.Err(), err)
+		}
+		return
+	}
+
+	return
+}
+
+// clone returns a new helper with the same options but nothing loaded
+func (p *SpiritHelper) clone() *SpiritHelper {
+	helper := *p
+	helper.Reset()
+	return &helper
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// BuildProfile is a named set of `go build` settings, e.g. debug with -race
+// or release with -trimpath and stripped symbols
+type BuildProfile struct {
+	// Flags are passed to `go build` as is, e.g.: ["-race", "-trimpath"]
+	Flags []string `json:"flags"`
+	// Tags are passed by -tags
+	Tags []string `json:"tags"`
+	// LDFlags are passed by -ldflags together with the build info flags,
+	// e.g.: ["-s", "-w"]
+	LDFlags []string `json:"ldflags"`
+	// Env are the environment variables of `go build`, format: key=val
+	Env []string `json:"env"`
+}
+
+// loadBuildProfiles reads the build profiles from json file, format:
+// {"release": {"flags": ["-trimpath"], "ldflags": ["-s", "-w"]}}
+func loadBuildProfiles(filename string) (profiles map[string]BuildProfile, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(data, &profiles); err != nil {
+		return
+	}
+
+	return
+}
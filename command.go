@@ -2,12 +2,158 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/codegangsta/cli"
 )
 
 type cliAction func(context *cli.Context)
 
+var verbosityFlag = cli.IntFlag{
+	Name:  "verbosity, v",
+	Usage: "How much troubleshooting info to print (1~5)",
+}
+
+// projectFlags are the flags shared by the commands which generate a project
+func projectFlags(flags ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "gopath",
+			Value: os.Getenv("GOPATH"),
+			Usage: "default gopath is get from $GOPATH",
+		}, cli.StringFlag{
+			Name:  "source-gopath",
+			Usage: "gopath of templates and default sources, default is gopath",
+		}, cli.StringFlag{
+			Name:  "output-gopath",
+			Usage: "gopath which the project path is under, default is gopath",
+		}, cli.StringFlag{
+			Name:  "config, c",
+			Value: "",
+			Usage: "config file, or http(s) url of config",
+		}, cli.DurationFlag{
+			Name:  "config-timeout",
+			Usage: "timeout of fetching config from url, default is 30s",
+		}, cli.BoolFlag{
+			Name:  "strict-config",
+			Usage: "fail if the config has unknown fields",
+		}, cli.StringFlag{
+			Name:   "config-auth",
+			Usage:  "Authorization header of fetching config from url, e.g.: \"Bearer token\"",
+			EnvVar: "SPIRIT_CONFIG_AUTH",
+		}, cli.StringFlag{
+			Name:  "config-name",
+			Usage: "file name of the config copied into project, default is the name of config file",
+		}, cli.BoolFlag{
+			Name:  "template-config",
+			Usage: "render the config as template with the args before it is parsed, e.g.: \"port\": //<-.args.port->//",
+		}, cli.StringFlag{
+			Name:  "config-path",
+			Usage: "the config path which the generated code loads when deployed, default is the config file name copied into project",
+		}, cli.StringFlag{
+			Name:  "config-format",
+			Usage: "format of config file, json, json5 or yaml, default is detected by the file extension",
+		}, cli.BoolFlag{
+			Name:  "normalize-config",
+			Usage: "write the json config copied into project re-encoded, json5 config is always written as strict json",
+		}, cli.StringFlag{
+			Name:  "output-config-format",
+			Usage: "write the config copied into project as json, yaml or toml, the extension of config name follows it, the template should read the format, default is json",
+		}, cli.StringFlag{
+			Name:  "template,t",
+			Value: "",
+			Usage: "which template to use, default is spirit_tool.template of config, or classic",
+		}, cli.StringFlag{
+			Name:  "template-root",
+			Usage: "the dir of templates, default is $GOPATH/src/github.com/gogap/spirit-tool/template",
+		}, cli.StringSliceFlag{
+			Name:  "source, s",
+			Usage: "your own source file, or dir of source files which is walked recursively",
+		}, cli.StringSliceFlag{
+			Name:  "args, a",
+			Usage: "the args will pass into template, format: -a key=val, you could use `args.key` to get value",
+		}, cli.StringSliceFlag{
+			Name:  "args-file",
+			Usage: "json, yaml or toml file of the args pass into template, the later file wins, the args of -a wins over it",
+		}, cli.StringFlag{
+			Name:  "args-env-prefix",
+			Usage: "read template args from environment variables with this prefix, e.g.: SPIRIT_ARG_",
+		}, cli.StringFlag{
+			Name:  "rev, r",
+			Usage: "packages revision config filepath, json or yaml format, e.g.: {\"github.com/gogap/spirit\":\"master\"}",
+		}, cli.StringSliceFlag{
+			Name:  "package, P",
+			Usage: "extra package which is not referenced by any urn, format: -P uri or -P uri=revision",
+		}, cli.StringSliceFlag{
+			Name:  "replace",
+			Usage: "replace the package resolved from sources, format: --replace uri=new_uri or --replace uri=new_uri@revision",
+		}, cli.StringFlag{
+			Name:  "urn-version-sep",
+			Usage: "separator of urn version suffix, e.g. #, then urn:foo#v2 falls back to urn:foo of sources if it is not in sources",
+		}, cli.BoolFlag{
+			Name:  "allow-empty",
+			Usage: "allow the config which references no urn",
+		}, cli.BoolFlag{
+			Name:  "allow-unresolved",
+			Usage: "warn about the urns not resolved instead of failing, their packages are skipped",
+		}, cli.StringSliceFlag{
+			Name:  "resolver",
+			Usage: "url of urn registry service which resolves the urns not in sources, GET url?urn=... responds {\"pkg\": \"\", \"revision\": \"\"}",
+		}, cli.StringFlag{
+			Name:  "deprecations",
+			Usage: "json file of deprecated urns, format: {\"urn:old\": {\"replacement\": \"urn:new\", \"message\": \"\"}}",
+		}, cli.BoolFlag{
+			Name:  "strict-urn",
+			Usage: "fail if one urn is used in more than one kind of config section",
+		}, cli.StringSliceFlag{
+			Name:  "allow-urn",
+			Usage: "the urn allowed to be used in more than one kind of config section in strict-urn mode",
+		}, cli.BoolFlag{
+			Name:  "verify-urn",
+			Usage: "warn about the urns not found in the source of their packages",
+		}, cli.BoolFlag{
+			Name:  "warnings-as-errors",
+			Usage: "fail if any warning is logged while creating project",
+		}, cli.StringFlag{
+			Name:  "goproxy",
+			Usage: "fetch packages through this GOPROXY list in module mode and copy them into GOPATH, default is $GOPROXY, direct falls back to vcs",
+		}, cli.BoolFlag{
+			Name:  "vendor",
+			Usage: "fetch packages into the vendor dir of project as a module instead of GOPATH, and build with -mod=vendor",
+		}, cli.StringSliceFlag{
+			Name:  "package-env",
+			Usage: "environment variable of fetching the packages with uri prefix, it wins over goproxy, format: --package-env prefix=KEY=VAL, e.g.: --package-env git.internal.com=GOFLAGS=-insecure",
+		}, cli.DurationFlag{
+			Name:  "fetch-timeout",
+			Usage: "timeout of fetching each package, e.g.: 2m, default is no timeout",
+		}, cli.StringFlag{
+			Name:  "service-name",
+			Usage: "name of the systemd unit and the files of layout, default is the base name of project",
+		}, cli.StringSliceFlag{
+			Name:  "cmd",
+			Usage: "entrypoint of project rendered into cmd/<name>/main.go instead of main.go, format: --cmd name or --cmd name=template",
+		}, cli.StringFlag{
+			Name:  "bin",
+			Usage: "the entrypoint built and run, default is the first --cmd",
+		}, cli.StringFlag{
+			Name:  "layout",
+			Usage: "stage the built project for packaging, fhs: binary under usr/bin, config under etc/<service-name>",
+		}, cli.StringFlag{
+			Name:  "staging-root",
+			Usage: "the dir relative to project which the layout is staged into, default is staging",
+		}, cli.StringFlag{
+			Name:  "log-file",
+			Usage: "append the logs of creating, fetching, building and running into this file with timestamp",
+		}, cli.BoolFlag{
+			Name:  "use-checkout",
+			Usage: "do not fetch the packages checked out in GOPATH, e.g. git submodules, lock them at their HEAD",
+		}, cli.StringFlag{
+			Name:  "since",
+			Usage: "with -u, only update the packages which have upstream commits after the date, e.g.: 2016-01-02",
+		},
+	}, flags...)
+}
+
 func commandUpgrade(action cliAction) cli.Command {
 	return cli.Command{
 		Name:      "upgrade",
@@ -15,10 +161,7 @@ func commandUpgrade(action cliAction) cli.Command {
 		Usage:     "Upgrade spirit-tool and reinstall",
 		Action:    action,
 		Flags: []cli.Flag{
-			cli.IntFlag{
-				Name:  "verbosity, v",
-				Usage: "How much troubleshooting info to print (1~5)",
-			},
+			verbosityFlag,
 		},
 	}
 }
@@ -45,46 +188,71 @@ func commandCreate(action cliAction) cli.Command {
 		ShortName: "",
 		Usage:     "Create src from template, it will create into $GOPATH/{PATH}",
 		Action:    action,
-		Flags: []cli.Flag{
+		Flags: projectFlags(
 			cli.StringFlag{
-				Name:  "gopath",
-				Value: os.Getenv("GOPATH"),
-				Usage: "default gopath is get from $GOPATH",
-			}, cli.StringFlag{
-				Name:  "config, c",
-				Value: "",
-				Usage: "config file",
-			}, cli.StringFlag{
 				Name:  "path, p",
 				Value: "",
 				Usage: "",
-			}, cli.StringFlag{
-				Name:  "template,t",
-				Value: "classic",
-				Usage: "which template to use, default is classic",
 			}, cli.BoolFlag{
 				Name:  "get, g",
 				Usage: "automatic get packages by `go get` command",
 			}, cli.BoolFlag{
 				Name:  "update, u",
 				Usage: "if get flag is ture, it will use `go get -u`",
-			}, cli.StringSliceFlag{
-				Name:  "source, s",
-				Usage: "your own source file",
-			}, cli.StringSliceFlag{
-				Name:  "args, a",
-				Usage: "the args will pass into template, format: -a key=val, you could use `args.key` to get value",
 			}, cli.BoolFlag{
 				Name:  "force, f",
 				Usage: "is your app is exist, it will overwrite it",
 			}, cli.StringFlag{
-				Name:  "rev, r",
-				Usage: "packages revision config filepath, json format, e.g.: {\"github.com/gogap/spirit\":\"master\"}",
-			}, cli.IntFlag{
-				Name:  "verbosity, v",
-				Usage: "How much troubleshooting info to print (1~5)",
+				Name:  "output-file",
+				Usage: "the file template rendered into, default is main.go, e.g. --output-file spirit_gen.go -t inject generates into existing project without touching main.go",
+			}, cli.BoolFlag{
+				Name:  "write-options",
+				Usage: "write the effective create options into project as spirit-options.json",
+			}, cli.BoolFlag{
+				Name:  "gitignore",
+				Usage: "write a .gitignore into project which ignores the built binary, vendor dir and os files",
+			}, cli.BoolFlag{
+				Name:  "embed-config",
+				Usage: "compile the config into binary as var embeddedConfig, use it with -t embedded",
+			}, cli.BoolFlag{
+				Name:  "tests",
+				Usage: "write a test into project which validates the shipped config",
+			}, cli.BoolFlag{
+				Name:  "systemd",
+				Usage: "write a systemd unit into project, its ExecStart is the binary of --build",
+			}, cli.StringSliceFlag{
+				Name:  "preserve",
+				Usage: "the file path or glob relative to project which is never overwritten, e.g.: --preserve README.md",
+			}, cli.BoolFlag{
+				Name:  "no-header",
+				Usage: "do not add the `Code generated ... DO NOT EDIT.` header to main.go",
+			}, cli.BoolFlag{
+				Name:  "no-fmt",
+				Usage: "do not go fmt the generated code",
+			}, cli.BoolFlag{
+				Name:  "no-syntax-check",
+				Usage: "do not parse the rendered go files before they are written",
+			}, cli.BoolFlag{
+				Name:  "lock",
+				Usage: "write the packages and their revisions into " + LockFileName + " of project, and report the packages no longer referenced since last lock",
+			}, cli.BoolFlag{
+				Name:  "prune",
+				Usage: "move the packages which are no longer referenced since last lock into $GOPATH/spirit-pruned, it writes the lock file as well",
+			}, cli.BoolFlag{
+				Name:  "vet",
+				Usage: "run `go vet` on the generated project",
+			}, cli.BoolFlag{
+				Name:  "interactive, i",
+				Usage: "prompt for the args required by template but not given",
+			}, cli.BoolFlag{
+				Name:  "build, b",
+				Usage: "build the binary into project after created",
+			}, cli.BoolFlag{
+				Name:  "quiet, q",
+				Usage: "only print the project path, or the binary path with --build, to stdout on success, errors go to stderr",
 			},
-		},
+			verbosityFlag,
+		),
 	}
 }
 
@@ -94,42 +262,58 @@ func commandRun(action cliAction) cli.Command {
 		ShortName: "",
 		Usage:     "run your spirit config directly",
 		Action:    action,
-		Flags: []cli.Flag{
-			cli.StringFlag{
-				Name:  "gopath",
-				Value: os.Getenv("GOPATH"),
-				Usage: "default gopath is get from $GOPATH",
-			}, cli.StringFlag{
-				Name:  "config, c",
-				Value: "",
-				Usage: "config file",
-			}, cli.StringFlag{
-				Name:  "template,t",
-				Value: "classic",
-				Usage: "which template to use, default is classic",
-			}, cli.BoolFlag{
+		Flags: projectFlags(
+			cli.BoolFlag{
 				Name:  "update, u",
 				Usage: "run `go get -u` before run",
-			}, cli.StringSliceFlag{
-				Name:  "source, s",
-				Usage: "your own source file",
-			}, cli.StringSliceFlag{
-				Name:  "args, a",
-				Usage: "the args will pass into template, format: -a key=val, you could use `args.key` to get value",
 			}, cli.StringFlag{
-				Name:  "rev, r",
-				Usage: "packages revision config filepath, json format, e.g.: {\"github.com/gogap/spirit\":\"master\"}",
+				Name:  "bin-dir",
+				Usage: "the dir relative to project which the binary is built into",
+			}, cli.StringFlag{
+				Name:  "version-var",
+				Usage: "set build info vars (BuildTime, BuildConfig, BuildToolVersion, BuildRevisions) in this package by -ldflags, e.g.: main",
+			}, cli.StringFlag{
+				Name:  "build-profiles",
+				Usage: "json file of named build profiles, format: {\"release\": {\"flags\": [\"-trimpath\"], \"tags\": [], \"ldflags\": [\"-s\", \"-w\"], \"env\": [\"CGO_ENABLED=0\"]}}",
+			}, cli.StringFlag{
+				Name:  "profile",
+				Usage: "the build profile to apply, it must be defined in build-profiles",
+			}, cli.DurationFlag{
+				Name:  "build-timeout",
+				Usage: "timeout of go build, e.g.: 5m, default is no timeout",
+			}, cli.StringSliceFlag{
+				Name:  "artifact",
+				Usage: "copy file after built, the relative path is under project, format: --artifact main=/deploy/app",
 			}, cli.BoolFlag{
 				Name:  "detach, d",
 				Usage: "Run spirit in background and print PID",
 			}, cli.StringSliceFlag{
 				Name:  "env, e",
 				Usage: "Set environment variables",
-			}, cli.IntFlag{
-				Name:  "verbosity, v",
-				Usage: "How much troubleshooting info to print (1~5)",
+			}, cli.StringSliceFlag{
+				Name:  "program-arg",
+				Usage: "the arg which the project is launched with, in order, e.g.: --program-arg=--port --program-arg=8080",
+			}, cli.StringSliceFlag{
+				Name:  "stop-signal",
+				Usage: "signals forwarded to spirit to stop it, default is TERM, INT and QUIT of tty reach spirit directly, if listed they are forwarded too and arrive twice",
+			}, cli.StringFlag{
+				Name:  "kill-signal",
+				Usage: "signal to kill spirit immediately, disabled by default",
+			}, cli.StringFlag{
+				Name:  "pre-stop",
+				Usage: "shell command run on the first stop signal before spirit receives it, the pid is $SPIRIT_PID",
+			}, cli.StringFlag{
+				Name:  "health-addr",
+				Usage: "wait until this address is healthy after spirit launched, format: host:port",
+			}, cli.StringFlag{
+				Name:  "health-path",
+				Usage: "check health by http GET of this path instead of tcp dialing",
+			}, cli.DurationFlag{
+				Name:  "health-timeout",
+				Usage: "health check timeout, default is 30s",
 			},
-		},
+			verbosityFlag,
+		),
 	}
 }
 
@@ -139,38 +323,277 @@ func commandBuild(action cliAction) cli.Command {
 		ShortName: "",
 		Usage:     "build your spirit with config",
 		Action:    action,
+		Flags: projectFlags(
+			cli.BoolFlag{
+				Name:  "update, u",
+				Usage: "run `go get -u` before build",
+			}, cli.StringFlag{
+				Name:  "output, o",
+				Usage: "the binary output path",
+			}, cli.StringFlag{
+				Name:  "version-var",
+				Usage: "set build info vars (BuildTime, BuildConfig, BuildToolVersion, BuildRevisions) in this package by -ldflags, e.g.: main",
+			}, cli.StringFlag{
+				Name:  "build-profiles",
+				Usage: "json file of named build profiles, format: {\"release\": {\"flags\": [\"-trimpath\"], \"tags\": [], \"ldflags\": [\"-s\", \"-w\"], \"env\": [\"CGO_ENABLED=0\"]}}",
+			}, cli.StringFlag{
+				Name:  "profile",
+				Usage: "the build profile to apply, it must be defined in build-profiles",
+			}, cli.DurationFlag{
+				Name:  "build-timeout",
+				Usage: "timeout of go build, e.g.: 5m, default is no timeout",
+			}, cli.StringSliceFlag{
+				Name:  "artifact",
+				Usage: "copy file after built, the relative path is under project, format: --artifact main=/deploy/app",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandDeps(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "deps",
+		ShortName: "",
+		Usage:     "print the packages used by your spirit config",
+		Action:    action,
+		Flags: projectFlags(
+			cli.BoolFlag{
+				Name:  "get, g",
+				Usage: "automatic get packages by `go get` command before print",
+			}, cli.BoolFlag{
+				Name:  "update, u",
+				Usage: "if get flag is ture, it will use `go get -u`",
+			}, cli.BoolFlag{
+				Name:  "transitive",
+				Usage: "also print the transitive dependencies of each package by `go list -deps`, it is expensive",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandPlan(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "plan",
+		ShortName: "",
+		Usage:     "print what would be fetched and updated without fetching",
+		Action:    action,
+		Flags: projectFlags(
+			cli.BoolFlag{
+				Name:  "update, u",
+				Usage: "plan as `go get -u`",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandBatch(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "batch",
+		ShortName: "",
+		Usage:     "Create projects listed in manifest, format: [{\"config\":\"\", \"template\":\"\", \"path\":\"\", \"args\":{}}]",
+		Action:    action,
+		Flags: projectFlags(
+			cli.StringFlag{
+				Name:  "manifest, m",
+				Usage: "manifest file of projects",
+			}, cli.IntFlag{
+				Name:  "concurrency",
+				Value: 1,
+				Usage: "how many projects are created at the same time",
+			}, cli.BoolFlag{
+				Name:  "get, g",
+				Usage: "automatic get packages by `go get` command",
+			}, cli.BoolFlag{
+				Name:  "update, u",
+				Usage: "if get flag is ture, it will use `go get -u`",
+			}, cli.BoolFlag{
+				Name:  "force, f",
+				Usage: "is your app is exist, it will overwrite it",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandLint(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "lint",
+		ShortName: "",
+		Usage:     "check the config and sources, exit with 1 if any error found",
+		Action:    action,
+		Flags: projectFlags(
+			cli.StringSliceFlag{
+				Name:  "disable",
+				Usage: "disable lint rule: " + strings.Join(LintRules, ", "),
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandHash(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "hash",
+		ShortName: "",
+		Usage:     "print the sha256 of the files, config and packages which create would generate, nothing is fetched or written",
+		Action:    action,
+		Flags: projectFlags(
+			cli.StringFlag{
+				Name:  "path, p",
+				Usage: "the project path, as create",
+			}, cli.StringFlag{
+				Name:  "output-file",
+				Usage: "the file template rendered into, default is main.go",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandScanSource(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "scan-source",
+		ShortName: "",
+		Usage:     "print the source config of the urns registered by the packages in GOPATH, usage: scan-source [package prefix]...",
+		Action:    action,
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "gopath",
 				Value: os.Getenv("GOPATH"),
 				Usage: "default gopath is get from $GOPATH",
+			},
+			verbosityFlag,
+		},
+	}
+}
+
+func commandSummary(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "summary",
+		ShortName: "",
+		Usage:     "print the actor count and urns of each config section",
+		Action:    action,
+		Flags: projectFlags(
+			cli.BoolFlag{
+				Name:  "all",
+				Usage: "print the empty sections too",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandUpdateConfig(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "update-config",
+		ShortName: "",
+		Usage:     "copy the config into the created project again without rendering template or fetching packages",
+		Action:    action,
+		Flags: projectFlags(
+			cli.StringFlag{
+				Name:  "path, p",
+				Usage: "the project path which is created",
 			}, cli.StringFlag{
-				Name:  "config, c",
-				Value: "",
-				Usage: "config file",
-			}, cli.StringFlag{
-				Name:  "template,t",
-				Value: "classic",
-				Usage: "which template to use, default is classic",
+				Name:  "output-file",
+				Usage: "the file which template was rendered into, default is main.go",
 			}, cli.BoolFlag{
-				Name:  "update, u",
-				Usage: "run `go get -u` before build",
+				Name:  "embed-config",
+				Usage: "rewrite the embedded config too, the project needs rebuild",
 			}, cli.StringSliceFlag{
-				Name:  "source, s",
-				Usage: "your own source file",
-			}, cli.StringSliceFlag{
-				Name:  "args, a",
-				Usage: "the args will pass into template, format: -a key=val, you could use `args.key` to get value",
-			}, cli.StringFlag{
-				Name:  "rev, r",
-				Usage: "packages revision config filepath, json format, e.g.: {\"github.com/gogap/spirit\":\"master\"}",
-			}, cli.StringFlag{
-				Name:  "output, o",
-				Usage: "the binary output path",
+				Name:  "preserve",
+				Usage: "the file path or glob relative to project which is never overwritten, e.g.: --preserve config.json",
 			}, cli.IntFlag{
-				Name:  "verbosity, v",
-				Usage: "How much troubleshooting info to print (1~5)",
+				Name:  "pid",
+				Usage: "send SIGHUP to the running project to reload the config",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandLintSource(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "lint-source",
+		ShortName: "",
+		Usage:     "check the source files without config, exit with 1 if any error found, usage: lint-source <file>...",
+		Action:    action,
+		Flags: []cli.Flag{
+			verbosityFlag,
+		},
+	}
+}
+
+func commandDoctor(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "doctor",
+		ShortName: "",
+		Usage:     "check the external tools go, git and gofmt are installed, exit with 1 if any is missing",
+		Action:    action,
+		Flags: projectFlags(
+			cli.BoolFlag{
+				Name:  "no-fmt",
+				Usage: "do not check gofmt",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandLockDiff(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "lock-diff",
+		ShortName: "",
+		Usage:     "print the added, removed and revision changed packages between two lock files, exit with 1 if any, usage: lock-diff <old> <new>",
+		Action:    action,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "report",
+				Usage: "only report the differences, exit with 0",
+			},
+			verbosityFlag,
+		},
+	}
+}
+
+func commandTemplateData(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "template-data",
+		ShortName: "",
+		Usage:     "print the data passed into template as json without executing it",
+		Action:    action,
+		Flags: projectFlags(
+			cli.StringFlag{
+				Name:  "path, p",
+				Value: ".",
+				Usage: "the project path of create_options",
+			},
+			verbosityFlag,
+		),
+	}
+}
+
+func commandNewTemplate(action cliAction) cli.Command {
+	return cli.Command{
+		Name:      "new-template",
+		ShortName: "",
+		Usage:     "Scaffold a new template under template root, usage: new-template <name>",
+		Action:    action,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "gopath",
+				Value: os.Getenv("GOPATH"),
+				Usage: "default gopath is get from $GOPATH",
+			}, cli.StringFlag{
+				Name:  "template-root",
+				Usage: "the dir of templates, default is $GOPATH/src/github.com/gogap/spirit-tool/template",
+			}, cli.BoolFlag{
+				Name:  "force, f",
+				Usage: "overwrite the template if it is exist",
 			},
+			verbosityFlag,
 		},
 	}
 }
@@ -1,11 +1,95 @@
 package main
 
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
 type URNPackage struct {
 	URN string `json:"urn"`
 	Pkg string `json:"pkg"`
 }
 
+// configDirective is the optional section of spirit config read by
+// spirit-tool, e.g.: {"spirit_tool": {"template": "classic"}}, the template
+// is used if no template name is given to CreateProject
+type configDirective struct {
+	SpiritTool struct {
+		Template string `json:"template"`
+	} `json:"spirit_tool"`
+}
+
 type SourceConfig struct {
 	UpdateTime string       `json:"update_time"`
 	Packages   []URNPackage `json:"packages"`
+	// Deprecated are the deprecated urns of this source
+	Deprecated map[string]URNDeprecation `json:"deprecated,omitempty"`
+}
+
+func loadSourceConfig(filename string) (sourceConf SourceConfig, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(data, &sourceConf); err != nil {
+		return
+	}
+
+	return
+}
+
+// AddSource registers the in-memory source, it is merged with the source
+// files when the urns are resolved, a urn mapped to different packages by
+// them is an error as the files, the added sources are kept by Reset
+func (p *SpiritHelper) AddSource(sourceConf SourceConfig) {
+	p.memSources = append(p.memSources, sourceConf)
+}
+
+// expandSources replaces the dirs in sources with the *.json files under
+// them recursively, symlinks to dirs, non-json files and the entries could
+// not be read are skipped
+func (p *SpiritHelper) expandSources(sources []string) (files []string, err error) {
+	for _, source := range sources {
+		if fi, e := os.Stat(source); e != nil || !fi.IsDir() {
+			files = append(files, source)
+			continue
+		}
+
+		err = filepath.Walk(source, func(filename string, info os.FileInfo, e error) error {
+			if e != nil {
+				p.logger().Debugf("source %s is skipped, %s", filename, e)
+				return nil
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			if info.Mode()&os.ModeSymlink != 0 {
+				if info, e = os.Stat(filename); e != nil || info.IsDir() {
+					p.logger().Debugf("source %s is skipped, it is a broken symlink or symlink to dir", filename)
+					return nil
+				}
+			}
+
+			if !info.Mode().IsRegular() || strings.ToLower(filepath.Ext(filename)) != ".json" {
+				p.logger().Debugf("source %s is skipped, it is not a json file", filename)
+				return nil
+			}
+
+			files = append(files, filename)
+
+			return nil
+		})
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
 }
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	"github.com/gogap/spirit"
+	"github.com/yosuke-furukawa/json5/encoding/json5"
+)
+
+const (
+	ConfigFormatJSON  = "json"
+	ConfigFormatJSON5 = "json5"
+	ConfigFormatYAML  = "yaml"
+	ConfigFormatTOML  = "toml"
+)
+
+// configFormat returns the format of loaded config, it is detected by the
+// file extension if not set by WithConfigFormat
+func (p *SpiritHelper) configFormat() string {
+	if p.format != "" {
+		return p.format
+	}
+
+	return configFileFormat(p.configFileName)
+}
+
+// configFileFormat returns the config format of filename by its extension,
+// default is json
+func configFileFormat(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".json5":
+		return ConfigFormatJSON5
+	case ".yaml", ".yml":
+		return ConfigFormatYAML
+	case ".toml":
+		return ConfigFormatTOML
+	}
+
+	return ConfigFormatJSON
+}
+
+func (p *SpiritHelper) unmarshalConfig(data []byte, v interface{}) error {
+	switch p.configFormat() {
+	case ConfigFormatJSON5:
+		return json5.Unmarshal(data, v)
+	case ConfigFormatYAML:
+		return yaml.Unmarshal(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// strictSpiritConfig is the spirit config with the spirit_tool directive,
+// which is the only extra key allowed in strict mode
+type strictSpiritConfig struct {
+	spirit.SpiritConfig
+	SpiritTool json.RawMessage `json:"spirit_tool"`
+}
+
+// unmarshalStrictConfig decodes the spirit config and fails on the unknown
+// fields, json5 and yaml are decoded as json after they are normalized
+func (p *SpiritHelper) unmarshalStrictConfig(data []byte, conf *spirit.SpiritConfig) (err error) {
+	switch p.configFormat() {
+	case ConfigFormatJSON5:
+		var v interface{}
+		if err = json5.Unmarshal(data, &v); err != nil {
+			return
+		}
+
+		if data, err = json.Marshal(v); err != nil {
+			return
+		}
+	case ConfigFormatYAML:
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return
+		}
+	}
+
+	strictConf := strictSpiritConfig{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(&strictConf); err != nil {
+		err = fmt.Errorf("strict config %s failed, it has unknown or mistyped field, %s", p.configFile, err)
+		return
+	}
+
+	*conf = strictConf.SpiritConfig
+
+	return
+}
+
+// projectConfig returns the content of config copied into project, it is the
+// loaded json config verbatim, or strict json if createOpts.NormalizeConfig is
+// set or the config is json5, or transcoded to createOpts.OutputConfigFormat,
+// then transformed by createOpts.ConfigTransform
+func (p *SpiritHelper) projectConfig(createOpts CreateOptions) (data []byte, err error) {
+	if data, err = p.encodeProjectConfig(createOpts); err != nil {
+		return
+	}
+
+	if createOpts.ConfigTransform != nil {
+		if data, err = createOpts.ConfigTransform(p.conf, data); err != nil {
+			err = fmt.Errorf("config transform failed, %s", err)
+			return
+		}
+	}
+
+	return
+}
+
+// configFormatsArg is the reserved arg of the config formats which the code
+// of template reads, default is json, e.g. in args.json:
+//
+//	"config_formats": ["json", "yaml"]
+const configFormatsArg = "config_formats"
+
+// checkConfigFormat returns error if the template of createOpts, or any of
+// its entrypoints, could not read the config copied into project, or the
+// extension of createOpts.ConfigFileName is not the format of it
+func (p *SpiritHelper) checkConfigFormat(createOpts CreateOptions, tmplArgs map[string]interface{}) (err error) {
+	format := p.projectConfigFormat(createOpts)
+	if format == "" {
+		format = ConfigFormatJSON
+	}
+
+	if createOpts.ConfigFileName != "" && configFileFormat(createOpts.ConfigFileName) != format {
+		err = fmt.Errorf("config file name %s does not match the %s config copied into project, use the extension of %s", createOpts.ConfigFileName, format, format)
+		return
+	}
+
+	optsList := []CreateOptions{createOpts}
+	for _, ep := range createOpts.Entrypoints {
+		optsList = append(optsList, createOpts.entrypointOptions(ep))
+	}
+
+	for _, opts := range optsList {
+		var args map[string]interface{}
+		if args, err = p.templateArgs(opts, tmplArgs); err != nil {
+			return
+		}
+
+		formats := []interface{}{ConfigFormatJSON}
+		if v, exist := args[configFormatsArg]; exist {
+			var ok bool
+			if formats, ok = v.([]interface{}); !ok {
+				err = fmt.Errorf("arg %s of template %s should be a list of config formats", configFormatsArg, opts.TemplateName)
+				return
+			}
+		}
+
+		readable := false
+		for _, f := range formats {
+			if f == format {
+				readable = true
+				break
+			}
+		}
+
+		if !readable {
+			err = fmt.Errorf("template %s reads config formats %v, it could not read the %s config, set --output-config-format to one of them", opts.TemplateName, formats, format)
+			return
+		}
+	}
+
+	return
+}
+
+// projectConfigFormat returns the format of config copied into project, it
+// is createOpts.OutputConfigFormat, or json if the loaded config is not json,
+// since the generated code reads it by encoding/json, empty keeps the config
+// as is
+func (p *SpiritHelper) projectConfigFormat(createOpts CreateOptions) string {
+	if createOpts.OutputConfigFormat != "" {
+		return createOpts.OutputConfigFormat
+	}
+
+	if p.configFormat() != ConfigFormatJSON {
+		return ConfigFormatJSON
+	}
+
+	return ""
+}
+
+// encodeProjectConfig returns the loaded config in the format of project
+func (p *SpiritHelper) encodeProjectConfig(createOpts CreateOptions) (data []byte, err error) {
+	format := p.projectConfigFormat(createOpts)
+
+	if !createOpts.NormalizeConfig && format == "" {
+		data = p.originalConfig
+		return
+	}
+
+	var v interface{}
+	if err = p.unmarshalConfig(p.originalConfig, &v); err != nil {
+		return
+	}
+
+	switch format {
+	case ConfigFormatYAML:
+		return yaml.Marshal(v)
+	case ConfigFormatTOML:
+		buf := &bytes.Buffer{}
+		if err = toml.NewEncoder(buf).Encode(v); err != nil {
+			return
+		}
+		data = buf.Bytes()
+		return
+	}
+
+	return json.MarshalIndent(v, "", "    ")
+}
+
+// outputConfigFileName replaces the extension of filename by the one of
+// format
+func outputConfigFileName(filename, format string) string {
+	if format == "" {
+		return filename
+	}
+	return strings.TrimSuffix(filename, path.Ext(filename)) + "." + format
+}
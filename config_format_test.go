@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckConfigFileNameFormat(t *testing.T) {
+	cases := []struct {
+		configFileName string
+		createOpts     CreateOptions
+		mismatch       bool
+	}{
+		{"spirit.json", CreateOptions{ConfigFileName: "config.json"}, false},
+		{"spirit.yaml", CreateOptions{ConfigFileName: "config.json"}, false},
+		{"spirit.yaml", CreateOptions{ConfigFileName: "config.yaml"}, true},
+		{"spirit.json", CreateOptions{ConfigFileName: "config.yml", OutputConfigFormat: ConfigFormatYAML}, false},
+		{"spirit.json", CreateOptions{ConfigFileName: "config.json", OutputConfigFormat: ConfigFormatTOML}, true},
+	}
+
+	for _, c := range cases {
+		helper := &SpiritHelper{configFileName: c.configFileName}
+
+		// the template is not found after the file name is checked
+		err := helper.checkConfigFormat(c.createOpts, nil)
+		if mismatch := err != nil && strings.HasPrefix(err.Error(), "config file name"); mismatch != c.mismatch {
+			t.Errorf("config %s copied as %s, mismatch is %v, want %v: %v", c.configFileName, c.createOpts.ConfigFileName, mismatch, c.mismatch, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// renderConfig executes the loaded config as template with the delims and
+// funcs of code template, the data is {"args": args}, then the rendered
+// config is parsed again, so the urns are extracted from it, the config is
+// rendered once, the placeholders are gone after it
+func (p *SpiritHelper) renderConfig(createOpts CreateOptions, tmplArgs map[string]interface{}) (err error) {
+	var args map[string]interface{}
+	if args, err = p.templateArgs(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	var tmpl *template.Template
+	if tmpl, err = template.New(p.configFileName).Funcs(templateFuncs).Option("missingkey=error").Delims(defaultLeftDelim, defaultRightDelim).Parse(string(p.originalConfig)); err != nil {
+		err = fmt.Errorf("parse config %s as template failed, %s", p.configFile, err)
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, map[string]interface{}{"args": args}); err != nil {
+		err = fmt.Errorf("render config %s failed, %s", p.configFile, err)
+		return
+	}
+
+	p.originalConfig = buffer.Bytes()
+
+	if err = p.parseConfig(); err != nil {
+		err = fmt.Errorf("parse rendered config %s failed, %s", p.configFile, err)
+		return
+	}
+
+	p.configErr = nil
+
+	return
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExpandSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"sources/a.json",
+		"sources/b.JSON",
+		"sources/readme.md",
+		"sources/nested/c.json",
+		"other/d.json",
+	}
+
+	for _, name := range files {
+		filename := path.Join(dir, name)
+		if err = os.MkdirAll(path.Dir(filename), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filename, []byte("{}"), os.FileMode(0644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = os.Symlink(path.Join(dir, "other"), path.Join(dir, "sources", "other")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Symlink(path.Join(dir, "missing.json"), path.Join(dir, "sources", "broken.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	helper := &SpiritHelper{}
+
+	sources, err := helper.expandSources([]string{
+		path.Join(dir, "sources"),
+		path.Join(dir, "other", "d.json"),
+		path.Join(dir, "missing.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(sources)
+
+	expected := []string{
+		path.Join(dir, "missing.json"),
+		path.Join(dir, "other", "d.json"),
+		path.Join(dir, "sources", "a.json"),
+		path.Join(dir, "sources", "b.JSON"),
+		path.Join(dir, "sources", "nested", "c.json"),
+	}
+
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expanded sources are %v, want %v", sources, expected)
+	}
+}
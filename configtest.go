@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"text/template"
+)
+
+// configTestFileName is the config validation test generated into project
+const configTestFileName = "spirit_config_test.go"
+
+const configTestTemplate = `package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gogap/spirit"
+)
+
+// generatedURNs are the urns resolved to packages when the project was
+// generated, the packages are imported by the generated code. It is a
+// snapshot, the registry of spirit at runtime is not consulted, so the urns
+// of config are checked against the generated code, not the registrations
+var generatedURNs = map[string]string{
+{{- range .URNs}}
+	{{printf "%q" .URN}}: {{printf "%q" .Pkg}},
+{{- end}}
+}
+
+func TestSpiritConfig(t *testing.T) {
+	data, err := ioutil.ReadFile({{printf "%q" .ConfigFileName}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := spirit.SpiritConfig{}
+	if err = json.Unmarshal(data, &conf); err != nil {
+		t.Fatalf("config could not be parsed, %s", err)
+	}
+
+	if err = conf.Validate(); err != nil {
+		t.Fatalf("config is invalid, %s", err)
+	}
+
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, urn := range configURNs(v) {
+		if _, exist := generatedURNs[urn]; !exist {
+			t.Errorf("urn %s of config was not resolved when the project was generated, regenerate the project", urn)
+		}
+	}
+}
+
+// configURNs returns the values of "urn" in config
+func configURNs(v interface{}) (urns []string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if urn, ok := child.(string); ok && key == "urn" {
+				urns = append(urns, urn)
+				continue
+			}
+			urns = append(urns, configURNs(child)...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			urns = append(urns, configURNs(child)...)
+		}
+	}
+	return
+}
+`
+
+// configTestData returns the source of config validation test, it checks the
+// urns of config against the snapshot of the urns resolved at generation
+func (p *SpiritHelper) configTestData(createOpts CreateOptions) (data []byte, err error) {
+	var urns []URNPackage
+	for urn, pkg := range p.urnPackages {
+		urns = append(urns, URNPackage{URN: urn, Pkg: pkg})
+	}
+	sort.Sort(urnPackages(urns))
+
+	var tmpl *template.Template
+	if tmpl, err = template.New("config_test").Parse(configTestTemplate); err != nil {
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, map[string]interface{}{
+		"URNs":           urns,
+		"ConfigFileName": p.projectConfigFileName(createOpts),
+	}); err != nil {
+		return
+	}
+
+	data = append([]byte(generatedHeader), buffer.Bytes()...)
+
+	return
+}
+
+type urnPackages []URNPackage
+
+func (p urnPackages) Len() int           { return len(p) }
+func (p urnPackages) Less(i, j int) bool { return p[i].URN < p[j].URN }
+func (p urnPackages) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+type generatedFilesByName []generatedFile
+
+func (p generatedFilesByName) Len() int           { return len(p) }
+func (p generatedFilesByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p generatedFilesByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// ContentHash returns the sha256 of what CreateProject would generate: the
+// rendered files, the optional files like the systemd unit and the embedded
+// config, the config copied into project and the packages with their
+// requested revisions, nothing is fetched or written, the create_time is the
+// unix epoch unless it is fixed by CreateTime or SOURCE_DATE_EPOCH, so the
+// hash is stable for the same inputs
+func (p *SpiritHelper) ContentHash(createOpts CreateOptions, tmplArgs map[string]interface{}) (hash string, err error) {
+	// nothing is fetched, so GOPATH/src is not created
+	createOpts.GetPackages = false
+
+	var goSrc string
+	if goSrc, err = p.loadProject(&createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	// the revisions are applied as GetPackages does, without fetching
+	var pkgRevision map[string]string
+	if pkgRevision, err = createOpts.packagesRevision(); err != nil {
+		return
+	}
+
+	p.applyPackagesRevision(goSrc, pkgRevision)
+
+	if createOpts.CreateTime.IsZero() && os.Getenv("SOURCE_DATE_EPOCH") == "" {
+		createOpts.CreateTime = time.Unix(0, 0).UTC()
+	}
+
+	if err = p.prepareRender(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	var generated []generatedFile
+	if generated, err = p.renderProject(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	var optionalFiles []generatedFile
+	if optionalFiles, err = p.optionalFiles(createOpts); err != nil {
+		return
+	}
+
+	generated = append(generated, optionalFiles...)
+
+	sort.Sort(generatedFilesByName(generated))
+
+	// each part is prefixed by its kind, name and length, so the parts could
+	// not be shifted into each other
+	h := sha256.New()
+	for _, file := range generated {
+		fmt.Fprintf(h, "file %s %d\n", file.Name, len(file.Data))
+		h.Write(file.Data)
+	}
+
+	fmt.Fprintf(h, "config %s %d\n", p.projectConfigFileName(createOpts), len(p.configContent))
+	h.Write(p.configContent)
+
+	for _, pkg := range p.RefPackages {
+		fmt.Fprintf(h, "package %s %s\n", pkg.URI, pkg.Revision)
+	}
+
+	hash = hex.EncodeToString(h.Sum(nil))
+
+	return
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+const contentHashTestTemplate = `package main
+
+//<-printf "import ("->//
+//<-range $_, $pkg := .packages->////<-printf "\t_ \"%s\"\n" $pkg.URI->////<-end->////<-printf ")"->//
+
+func main() {}
+`
+
+const contentHashTestConfig = `{
+    "components": [
+        {"name": "a", "urn": "urn:test:a"},
+        {"name": "b", "urn": "urn:test:b"},
+        {"name": "c", "urn": "urn:test:c"},
+        {"name": "d", "urn": "urn:test:d"},
+        {"name": "e", "urn": "urn:test:e"}
+    ]
+}`
+
+const contentHashTestSource = `{
+    "packages": [
+        {"urn": "urn:test:a", "pkg": "github.com/test/a"},
+        {"urn": "urn:test:b", "pkg": "github.com/test/b"},
+        {"urn": "urn:test:c", "pkg": "github.com/test/c"},
+        {"urn": "urn:test:d", "pkg": "github.com/test/d"},
+        {"urn": "urn:test:e", "pkg": "github.com/test/e"}
+    ]
+}`
+
+// contentHashTest writes the template, config and source into dir and
+// returns the content hash of project created by them
+func contentHashTest(t *testing.T, dir, tmpl, config string, createOpts CreateOptions) string {
+	files := map[string]string{
+		"template/test/main.go": tmpl,
+		"config.json":           config,
+		"source.json":           contentHashTestSource,
+		"gopath/src/.keep":      "",
+	}
+
+	for name, data := range files {
+		filename := path.Join(dir, name)
+		if err := os.MkdirAll(path.Dir(filename), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(data), os.FileMode(0644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	helper, err := NewSpiritHelper(WithTemplateRoot(path.Join(dir, "template")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = helper.LoadSpiritConfig(path.Join(dir, "config.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	createOpts.TemplateName = "test"
+	createOpts.GoPath = path.Join(dir, "gopath")
+	createOpts.ProjectPath = "github.com/test/project"
+	createOpts.Sources = []string{path.Join(dir, "source.json")}
+
+	contentHash, err := helper.ContentHash(createOpts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return contentHash
+}
+
+func TestContentHashIsStable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first := contentHashTest(t, dir, contentHashTestTemplate, contentHashTestConfig, CreateOptions{})
+	for i := 0; i < 5; i++ {
+		if h := contentHashTest(t, dir, contentHashTestTemplate, contentHashTestConfig, CreateOptions{}); h != first {
+			t.Fatalf("content hash of the same inputs changed from %s to %s", first, h)
+		}
+	}
+}
+
+func TestContentHashChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	allFiles := CreateOptions{SystemdUnit: true, GenerateGitignore: true, EmbedConfig: true, GenerateTests: true}
+
+	base := contentHashTest(t, dir, contentHashTestTemplate, contentHashTestConfig, CreateOptions{})
+	baseAllFiles := contentHashTest(t, dir, contentHashTestTemplate, contentHashTestConfig, allFiles)
+
+	changedConfig := strings.Replace(contentHashTestConfig, `"name": "e"`, `"name": "f"`, 1)
+	changedTemplate := contentHashTestTemplate + "\n// changed\n"
+
+	cases := map[string]struct {
+		base       string
+		tmpl       string
+		config     string
+		createOpts CreateOptions
+	}{
+		"config":            {base, contentHashTestTemplate, changedConfig, CreateOptions{}},
+		"template":          {base, changedTemplate, contentHashTestConfig, CreateOptions{}},
+		"systemd unit":      {base, contentHashTestTemplate, contentHashTestConfig, CreateOptions{SystemdUnit: true}},
+		"gitignore":         {base, contentHashTestTemplate, contentHashTestConfig, CreateOptions{GenerateGitignore: true}},
+		"embedded config":   {base, contentHashTestTemplate, contentHashTestConfig, CreateOptions{EmbedConfig: true}},
+		"config test":       {base, contentHashTestTemplate, contentHashTestConfig, CreateOptions{GenerateTests: true}},
+		"all files config":  {baseAllFiles, contentHashTestTemplate, changedConfig, allFiles},
+		"all files service": {baseAllFiles, contentHashTestTemplate, contentHashTestConfig, CreateOptions{SystemdUnit: true, ServiceName: "other", GenerateGitignore: true, EmbedConfig: true, GenerateTests: true}},
+	}
+
+	for name, c := range cases {
+		if h := contentHashTest(t, dir, c.tmpl, c.config, c.createOpts); h == c.base {
+			t.Errorf("content hash is not changed by %s", name)
+		}
+	}
+}
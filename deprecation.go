@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// URNDeprecation describes a deprecated urn, Replacement is the urn to use
+// instead, it could be empty
+type URNDeprecation struct {
+	Replacement string `json:"replacement"`
+	Message     string `json:"message"`
+}
+
+// loadDeprecatedURNs reads the deprecated urns from json file, format:
+// {"urn:old": {"replacement": "urn:new", "message": ""}}
+func loadDeprecatedURNs(filename string) (deprecations map[string]URNDeprecation, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(data, &deprecations); err != nil {
+		return
+	}
+
+	return
+}
+
+// warnDeprecatedURNs warns about the deprecated urns referenced by config,
+// each urn is warned once
+func (p *SpiritHelper) warnDeprecatedURNs(urns []string, deprecations map[string]URNDeprecation) {
+	warned := map[string]bool{}
+
+	for _, urn := range urns {
+		deprecation, exist := deprecations[urn]
+		if !exist || warned[urn] {
+			continue
+		}
+		warned[urn] = true
+
+		msg := "urn " + urn + " is deprecated"
+		if deprecation.Replacement != "" {
+			msg += ", use " + deprecation.Replacement + " instead"
+		}
+		if deprecation.Message != "" {
+			msg += ", " + deprecation.Message
+		}
+
+		p.logger().Warnf("%s", msg)
+	}
+}
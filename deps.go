@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// PrintDependencies writes the packages referenced by the loaded config to w,
+// if transitive is true, the non-standard go dependencies of every package
+// are listed below it by `go list -deps`, so the packages must already exist
+// in GOPATH, set createOpts.GetPackages to fetch them first
+func (p *SpiritHelper) PrintDependencies(w io.Writer, createOpts CreateOptions, transitive bool) (err error) {
+	if createOpts.GoPath == "" {
+		err = ErrGoPathIsEmpty
+		return
+	}
+
+	if err = createOpts.checkGoSrc(); err != nil {
+		return
+	}
+
+	goSrc := path.Join(createOpts.GoPath, "src")
+
+	if err = p.parse(goSrc, createOpts); err != nil {
+		return
+	}
+
+	p.appendExtraPackages(goSrc, createOpts.ExtraPackages)
+
+	if err = p.applyPackagesHook(goSrc, createOpts); err != nil {
+		return
+	}
+
+	if createOpts.GetPackages {
+		if err = p.GetPackages(createOpts); err != nil {
+			return
+		}
+	}
+
+	var uris []string
+	for _, pkg := range p.RefPackages {
+		uris = append(uris, pkg.URI)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		fmt.Fprintln(w, uri)
+
+		if !transitive {
+			continue
+		}
+
+		var deps []string
+		if deps, err = listDependencies(uri); err != nil {
+			return
+		}
+
+		for _, dep := range deps {
+			fmt.Fprintf(w, "    %s\n", dep)
+		}
+	}
+
+	return
+}
+
+// listDependencies returns the transitive dependencies of the package uri,
+// the standard library packages and the package itself are excluded
+func listDependencies(uri string) (deps []string, err error) {
+	var out []byte
+	if out, err = execCommand("go list -deps " + uri); err != nil {
+		err = fmt.Errorf("list dependencies of %s failed, %s: %s", uri, err, strings.TrimSpace(string(out)))
+		return
+	}
+
+	for _, dep := range strings.Fields(string(out)) {
+		if dep == uri || !strings.Contains(strings.Split(dep, "/")[0], ".") {
+			continue
+		}
+		deps = append(deps, dep)
+	}
+
+	sort.Strings(deps)
+
+	return
+}
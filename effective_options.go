@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// EffectiveOptionsFileName is the default file which the effective create
+// options are written into
+const EffectiveOptionsFileName = "spirit-options.json"
+
+// EffectiveOptions returns createOpts with the defaults and the values of
+// environment filled in, as CreateProject resolves them
+func (p *SpiritHelper) EffectiveOptions(createOpts CreateOptions) (opts CreateOptions, err error) {
+	opts = createOpts
+
+	if opts.TemplateName == "" {
+		opts.TemplateName = p.directive.SpiritTool.Template
+	}
+
+	if opts.TemplateName == "" {
+		opts.TemplateName = DefaultTemplateName
+	}
+
+	if opts.PackagesRevision, err = createOpts.packagesRevision(); err != nil {
+		return
+	}
+
+	opts.SourceGoPath = createOpts.sourceGoPath()
+	opts.OutputGoPath = createOpts.outputGoPath()
+	opts.GoProxy = createOpts.goProxy()
+	// the entrypoints have their own outputs
+	if len(opts.Entrypoints) == 0 {
+		opts.OutputFileName = createOpts.outputFileName()
+	}
+	opts.ConfigFileName = p.projectConfigFileName(createOpts)
+	opts.ConfigRuntimePath = createOpts.configRuntimePath(opts.ConfigFileName)
+	opts.ServiceName = createOpts.systemdServiceName()
+
+	if opts.CreateTime = p.createTime; opts.CreateTime.IsZero() {
+		if opts.CreateTime, err = createOpts.createTime(); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// writeEffectiveOptions writes the effective create options into project as
+// json, so the creation could be reviewed and reproduced
+func (p *SpiritHelper) writeEffectiveOptions(createOpts CreateOptions, projectPath string) (err error) {
+	var opts CreateOptions
+	if opts, err = p.EffectiveOptions(createOpts); err != nil {
+		return
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(opts, "", "    "); err != nil {
+		return
+	}
+
+	if _, err = p.writeProjectFile(createOpts, projectPath, EffectiveOptionsFileName, append(data, '\n')); err != nil {
+		return
+	}
+
+	return
+}
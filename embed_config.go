@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmbedConfigWithEntrypoints = errors.New("embedded config is not supported with entrypoints, it is written into project dir which the entrypoints are not in")
+)
+
+// embeddedConfigFileName is the generated file which holds the config
+// compiled into binary
+const embeddedConfigFileName = "spirit_embedded_config.go"
+
+// embeddedConfigData returns the source of embeddedConfig, it is the config
+// copied into project
+func (p *SpiritHelper) embeddedConfigData() []byte {
+	return []byte(fmt.Sprintf("%spackage main\n\n// embeddedConfig is the spirit config compiled into binary\nvar embeddedConfig = []byte(%q)\n",
+		generatedHeader, p.configContent))
+}
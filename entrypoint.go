@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	ErrOutputFileWithEntrypoints = errors.New("output file name is not supported with entrypoints, each entrypoint has its own output")
+)
+
+// Entrypoint is a binary of project, the main.go of Template is rendered into
+// Output, e.g. cmd/receiver/main.go, all the entrypoints share the config and
+// packages of project
+type Entrypoint struct {
+	Name     string
+	Template string
+	Output   string
+}
+
+// entrypointOptions returns the create options of rendering ep, the template
+// is default the one of project
+func (p *CreateOptions) entrypointOptions(ep Entrypoint) CreateOptions {
+	opts := *p
+	if ep.Template != "" {
+		opts.TemplateName = ep.Template
+	}
+	opts.OutputFileName = ep.Output
+	return opts
+}
+
+// selectedEntrypoint returns the entrypoint of SelectedEntrypoint, default is
+// the first one, ok is false if project has no entrypoints
+func (p *CreateOptions) selectedEntrypoint() (ep Entrypoint, ok bool) {
+	for _, e := range p.Entrypoints {
+		if p.SelectedEntrypoint == "" || e.Name == p.SelectedEntrypoint {
+			return e, true
+		}
+	}
+	return
+}
+
+// validateEntrypoints checks the entrypoints have distinct names, existing
+// templates and relative outputs, and OutputFileName is not set with them
+func (p *CreateOptions) validateEntrypoints() (err error) {
+	if len(p.Entrypoints) > 0 && p.OutputFileName != "" {
+		err = ErrOutputFileWithEntrypoints
+		return
+	}
+
+	names := map[string]bool{}
+
+	for _, ep := range p.Entrypoints {
+		if ep.Name == "" || names[ep.Name] {
+			err = fmt.Errorf("entrypoint name should be unique and not empty: %q", ep.Name)
+			return
+		}
+		names[ep.Name] = true
+
+		if ep.Output == "" || path.IsAbs(ep.Output) || strings.HasPrefix(path.Clean(ep.Output), "..") || path.Dir(ep.Output) == "." {
+			err = fmt.Errorf("output of entrypoint %s should be a file in a sub dir of project, e.g. cmd/%s/main.go: %s", ep.Name, ep.Name, ep.Output)
+			return
+		}
+
+		opts := p.entrypointOptions(ep)
+		if _, e := os.Stat(path.Join(opts.templateDir(), "main.go")); e != nil {
+			err = fmt.Errorf("template %s of entrypoint %s not found, %s", opts.TemplateName, ep.Name, e)
+			return
+		}
+	}
+
+	if p.SelectedEntrypoint != "" && !names[p.SelectedEntrypoint] {
+		err = fmt.Errorf("entrypoint %s not found", p.SelectedEntrypoint)
+		return
+	}
+
+	return
+}
+
+// parseEntrypoints parses the entrypoints of format name or name=template,
+// the output is cmd/name/main.go
+func parseEntrypoints(values []string) (entrypoints []Entrypoint, err error) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		v := strings.SplitN(value, "=", 2)
+		ep := Entrypoint{Name: v[0], Output: path.Join("cmd", v[0], "main.go")}
+		if len(v) == 2 {
+			ep.Template = v[1]
+		}
+
+		if ep.Name == "" || strings.Contains(ep.Name, "/") {
+			err = fmt.Errorf("the entrypoint format error, entrypoint: %s", value)
+			return
+		}
+
+		entrypoints = append(entrypoints, ep)
+	}
+
+	return
+}
+
+// renderProject renders the template of project, or the template of each
+// entrypoint if there are entrypoints
+func (p *SpiritHelper) renderProject(createOpts CreateOptions, tmplArgs map[string]interface{}) (generated []generatedFile, err error) {
+	if len(createOpts.Entrypoints) == 0 {
+		var args map[string]interface{}
+		if args, err = p.templateArgs(createOpts, tmplArgs); err != nil {
+			return
+		}
+		return p.renderTemplateFiles(createOpts, args)
+	}
+
+	for _, ep := range createOpts.Entrypoints {
+		opts := createOpts.entrypointOptions(ep)
+
+		p.logger().Infof("render entrypoint %s by template %s: %s", ep.Name, opts.TemplateName, ep.Output)
+
+		var args map[string]interface{}
+		if args, err = p.templateArgs(opts, tmplArgs); err != nil {
+			return
+		}
+
+		var files []generatedFile
+		if files, err = p.renderTemplateFiles(opts, args); err != nil {
+			err = fmt.Errorf("entrypoint %s, %s", ep.Name, err)
+			return
+		}
+
+		generated = append(generated, files...)
+	}
+
+	return
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+)
+
+// gitignoreCruft are the files of os and editors ignored by .gitignore
+var gitignoreCruft = []string{".DS_Store", "Thumbs.db", "*.swp", "*~"}
+
+// gitignoreData returns the .gitignore of project, it ignores the binary
+// built by BuildOnly, the vendor dir in vendor mode and the os cruft
+func (p *SpiritHelper) gitignoreData(createOpts CreateOptions) []byte {
+	buffer := &bytes.Buffer{}
+
+	fmt.Fprintln(buffer, "# built binary")
+	if binDir := path.Clean(createOpts.BinOutputDir); binDir != "." && !path.IsAbs(binDir) {
+		fmt.Fprintf(buffer, "/%s/\n", binDir)
+	} else {
+		fmt.Fprintln(buffer, "/main")
+	}
+
+	if createOpts.Vendor {
+		fmt.Fprintln(buffer, "\n# packages vendored by spirit-tool")
+		fmt.Fprintln(buffer, "/vendor/")
+	}
+
+	fmt.Fprintln(buffer, "\n# os and editor files")
+	for _, pattern := range gitignoreCruft {
+		fmt.Fprintln(buffer, pattern)
+	}
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultHealthCheckTimeout  = 30 * time.Second
+	defaultHealthCheckInterval = time.Second
+)
+
+// HealthCheckOptions describes how RunProject waits for the launched project
+// to be healthy, if Path is empty the Address is checked by tcp dialing,
+// otherwise http://{Address}{Path} should response 2xx
+type HealthCheckOptions struct {
+	Address  string
+	Path     string
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+func (p *HealthCheckOptions) check() (err error) {
+	if p.Path == "" {
+		var conn net.Conn
+		if conn, err = net.DialTimeout("tcp", p.Address, p.interval()); err != nil {
+			return
+		}
+		conn.Close()
+		return
+	}
+
+	client := http.Client{Timeout: p.interval()}
+
+	var resp *http.Response
+	if resp, err = client.Get("http://" + p.Address + p.Path); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("status code is %d", resp.StatusCode)
+		return
+	}
+
+	return
+}
+
+// Wait polls the health check until it passed or timeout
+func (p *HealthCheckOptions) Wait() (err error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if err = p.check(); err == nil {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			err = fmt.Errorf("health check of %s%s failed after %s, %s", p.Address, p.Path, timeout, err)
+			return
+		}
+
+		time.Sleep(p.interval())
+	}
+}
+
+func (p *HealthCheckOptions) interval() time.Duration {
+	if p.Interval <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return p.Interval
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLoggerIsNil                = errors.New("logger is nil")
+	ErrHTTPOptionsWithLocalConfig = errors.New("http timeout and auth are only used by remote config, the config is a local file")
+)
+
+// Option configures the SpiritHelper created by NewSpiritHelper
+type Option func(helper *SpiritHelper) error
+
+// NewSpiritHelper creates a SpiritHelper configured by opts, the options are
+// validated together, then the config of WithConfig is loaded
+func NewSpiritHelper(opts ...Option) (helper *SpiritHelper, err error) {
+	h := &SpiritHelper{}
+
+	for _, opt := range opts {
+		if err = opt(h); err != nil {
+			return
+		}
+	}
+
+	if err = h.validateOptions(); err != nil {
+		return
+	}
+
+	if h.configFile != "" {
+		if err = h.LoadSpiritConfig(h.configFile); err != nil {
+			return
+		}
+	}
+
+	helper = h
+
+	return
+}
+
+// validateOptions returns the error of the options could not work with the
+// config of WithConfig, the config loaded later is not checked
+func (p *SpiritHelper) validateOptions() (err error) {
+	if p.configFile == "" {
+		return
+	}
+
+	name := p.configFile
+
+	if isRemoteConfig(p.configFile) {
+		var u *url.URL
+		if u, err = url.Parse(p.configFile); err != nil {
+			return
+		}
+		name = u.Path
+	} else if p.httpAuth != "" || p.httpTimeout > 0 {
+		err = ErrHTTPOptionsWithLocalConfig
+		return
+	}
+
+	// the extension of config is only checked if it is one of the formats
+	if format := configFileFormat(name); p.format != "" && p.format != format &&
+		(format != ConfigFormatJSON || strings.ToLower(path.Ext(name)) == ".json") {
+		err = fmt.Errorf("config format %s does not match the %s config: %s", p.format, format, p.configFile)
+		return
+	}
+
+	return
+}
+
+// WithConfig loads the config from file or http(s) url after the other
+// options are applied, it is the same as LoadSpiritConfig
+func WithConfig(filename string) Option {
+	return func(helper *SpiritHelper) error {
+		if filename == "" {
+			return ErrConfigFileNameIsEmpty
+		}
+		helper.configFile = filename
+		return nil
+	}
+}
+
+// WithLogger sets the logger of SpiritHelper
+func WithLogger(logger Logger) Option {
+	return func(helper *SpiritHelper) error {
+		if logger == nil {
+			return ErrLoggerIsNil
+		}
+		helper.Logger = logger
+		return nil
+	}
+}
+
+// WithTemplateRoot sets the dir which contains the templates, default is
+// $GOPATH/src/github.com/gogap/spirit-tool/template
+func WithTemplateRoot(dir string) Option {
+	return func(helper *SpiritHelper) error {
+		if dir == "" {
+			return fmt.Errorf("template root is empty")
+		}
+
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+
+		helper.templateRoot = root
+		return nil
+	}
+}
+
+// WithHTTPTimeout sets the timeout of fetching the config from url, default
+// is 30s
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(helper *SpiritHelper) error {
+		if timeout <= 0 {
+			return fmt.Errorf("http timeout should be positive: %s", timeout)
+		}
+		helper.httpTimeout = timeout
+		return nil
+	}
+}
+
+// WithHTTPAuth sets the Authorization header of fetching the config from url,
+// e.g.: Bearer token
+func WithHTTPAuth(auth string) Option {
+	return func(helper *SpiritHelper) error {
+		helper.httpAuth = auth
+		return nil
+	}
+}
+
+// WithStrictConfig makes LoadSpiritConfig fail on the unknown fields of
+// config, e.g. typos and obsolete keys, instead of dropping them
+func WithStrictConfig() Option {
+	return func(helper *SpiritHelper) error {
+		helper.strict = true
+		return nil
+	}
+}
+
+// WithConfigFormat sets the format of config file, json, json5 or yaml,
+// default is detected by the file extension
+func WithConfigFormat(format string) Option {
+	return func(helper *SpiritHelper) error {
+		switch format {
+		case ConfigFormatJSON, ConfigFormatJSON5, ConfigFormatYAML:
+		default:
+			return fmt.Errorf("unknown config format: %s", format)
+		}
+		helper.format = format
+		return nil
+	}
+}
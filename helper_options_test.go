@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestNewSpiritHelperValidatesOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"spirit.json", "spirit.conf"} {
+		if err = ioutil.WriteFile(path.Join(dir, name), []byte("{}"), os.FileMode(0644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		opts  []Option
+		valid bool
+	}{
+		{"http auth with local config", []Option{WithConfig(path.Join(dir, "spirit.json")), WithHTTPAuth("Bearer token")}, false},
+		{"http timeout with local config", []Option{WithHTTPTimeout(time.Second), WithConfig(path.Join(dir, "spirit.json"))}, false},
+		{"format against extension of remote config", []Option{WithConfig("https://example.com/spirit.yaml?rev=1"), WithConfigFormat(ConfigFormatJSON5)}, false},
+		{"format against extension of local config", []Option{WithConfig(path.Join(dir, "spirit.json")), WithConfigFormat(ConfigFormatYAML)}, false},
+		{"non-positive http timeout", []Option{WithHTTPTimeout(0)}, false},
+		{"empty config", []Option{WithConfig("")}, false},
+		{"http options without config", []Option{WithHTTPAuth("Bearer token"), WithHTTPTimeout(time.Second)}, true},
+		{"format of config without extension", []Option{WithConfig(path.Join(dir, "spirit.conf")), WithConfigFormat(ConfigFormatYAML)}, true},
+		{"format same as extension", []Option{WithConfigFormat(ConfigFormatJSON), WithConfig(path.Join(dir, "spirit.json"))}, true},
+	}
+
+	for _, c := range cases {
+		helper, err := NewSpiritHelper(c.opts...)
+		if c.valid && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("%s: should be rejected", c.name)
+		}
+
+		if err == nil && helper.configFile != "" && helper.originalConfig == nil {
+			t.Errorf("%s: config is not loaded", c.name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"path"
+)
+
+// LayoutFHS stages the built project in FHS layout for deb/rpm packaging:
+// the binary is usr/bin/ServiceName, the config is under etc/ServiceName and
+// the systemd unit is under lib/systemd/system
+const LayoutFHS = "fhs"
+
+// defaultStagingDir is the staging root relative to project
+const defaultStagingDir = "staging"
+
+// stagingRoot returns the dir which the layout is staged into
+func (p *CreateOptions) stagingRoot() string {
+	root := p.StagingRoot
+	if root == "" {
+		root = defaultStagingDir
+	}
+	if path.IsAbs(root) {
+		return root
+	}
+	return path.Join(p.projectDir(), root)
+}
+
+// fhsBinaryPath returns the path of binary of the installed package
+func (p *CreateOptions) fhsBinaryPath() string {
+	return path.Join("/usr/bin", p.systemdServiceName())
+}
+
+// fhsConfigDir returns the config dir of the installed package
+func (p *CreateOptions) fhsConfigDir() string {
+	return path.Join("/etc", p.systemdServiceName())
+}
+
+// stageLayout copies the binary built into binPath, the config and the
+// systemd unit of project into the staging root by createOpts.Layout
+func (p *SpiritHelper) stageLayout(createOpts CreateOptions, binPath string) (err error) {
+	if createOpts.Layout != LayoutFHS {
+		return
+	}
+
+	projectPath := createOpts.projectDir()
+	root := createOpts.stagingRoot()
+	configName := p.projectConfigFileName(createOpts)
+
+	files := []Artifact{
+		{Src: binPath, Dst: createOpts.fhsBinaryPath()},
+		{Src: path.Join(projectPath, configName), Dst: path.Join(createOpts.fhsConfigDir(), configName)},
+	}
+
+	if createOpts.SystemdUnit {
+		unitName := createOpts.systemdServiceName() + ".service"
+		files = append(files, Artifact{Src: path.Join(projectPath, unitName), Dst: path.Join("/lib/systemd/system", unitName)})
+	}
+
+	for _, file := range files {
+		if err = copyFile(file.Src, path.Join(root, file.Dst)); err != nil {
+			return
+		}
+	}
+
+	p.logger().Infof("project is staged in %s layout: %s", createOpts.Layout, root)
+
+	return
+}
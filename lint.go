@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+const (
+	LintRuleDuplicateNames = "duplicate-names"
+	LintRuleURNContexts    = "urn-contexts"
+	LintRuleUnresolvedURNs = "unresolved-urns"
+	LintRuleDeprecatedURNs = "deprecated-urns"
+	LintRuleUnusedSources  = "unused-sources"
+	LintRuleEmptyURNs      = "empty-urns"
+)
+
+// LintRules are all the rules run by Lint
+var LintRules = []string{
+	LintRuleDuplicateNames,
+	LintRuleURNContexts,
+	LintRuleUnresolvedURNs,
+	LintRuleDeprecatedURNs,
+	LintRuleUnusedSources,
+	LintRuleEmptyURNs,
+}
+
+// LintFinding is a problem of config found by Lint
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (p LintFinding) String() string {
+	return fmt.Sprintf("%-7s %s: %s", p.Severity, p.Rule, p.Message)
+}
+
+// Lint checks the loaded config and sources by the rules not disabled by
+// createOpts.DisabledLintRules, nothing is fetched or generated
+func (p *SpiritHelper) Lint(createOpts CreateOptions) (findings []LintFinding, err error) {
+	known := map[string]bool{}
+	for _, rule := range LintRules {
+		known[rule] = true
+	}
+
+	disabled := map[string]bool{}
+	for _, rule := range createOpts.DisabledLintRules {
+		if !known[rule] {
+			err = fmt.Errorf("unknown lint rule: %s", rule)
+			return
+		}
+		disabled[rule] = true
+	}
+
+	report := func(rule, severity, format string, v ...interface{}) {
+		if !disabled[rule] {
+			findings = append(findings, LintFinding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, v...)})
+		}
+	}
+
+	for _, section := range p.actorSections() {
+		for _, clash := range duplicateActors(section) {
+			report(LintRuleDuplicateNames, LintSeverityError, "%s", clash)
+		}
+
+		for _, actor := range section.Actors {
+			if actor.URN == "" {
+				report(LintRuleEmptyURNs, LintSeverityError, "actor %s of section %s has no urn", actor.Name, section.Name)
+			}
+		}
+	}
+
+	allowed := map[string]bool{}
+	for _, urn := range createOpts.URNContextWhitelist {
+		allowed[urn] = true
+	}
+
+	urnSections := p.urnSections()
+
+	var urns []string
+	for urn := range urnSections {
+		if urn != "" {
+			urns = append(urns, urn)
+		}
+	}
+	sort.Strings(urns)
+
+	for _, urn := range urns {
+		if len(urnSections[urn]) > 1 && !allowed[urn] {
+			report(LintRuleURNContexts, LintSeverityWarning, "%s is used in %s", urn, strings.Join(urnSections[urn], ", "))
+		}
+	}
+
+	var sources []string
+	if sources, err = p.expandSources(createOpts.Sources); err != nil {
+		return
+	}
+
+	resolved := map[string]bool{}
+	deprecated := map[string]URNDeprecation{}
+
+	for _, source := range sources {
+		var sourceConf SourceConfig
+		if sourceConf, err = loadSourceConfig(source); err != nil {
+			return
+		}
+
+		used := false
+		for _, urnPkg := range sourceConf.Packages {
+			resolved[urnPkg.URN] = true
+			if _, exist := urnSections[urnPkg.URN]; exist {
+				used = true
+			}
+		}
+
+		if !used {
+			report(LintRuleUnusedSources, LintSeverityWarning, "source %s provides no urn used by config", source)
+		}
+
+		for urn, deprecation := range sourceConf.Deprecated {
+			deprecated[urn] = deprecation
+		}
+	}
+
+	for urn, deprecation := range createOpts.DeprecatedURNs {
+		deprecated[urn] = deprecation
+	}
+
+	for _, urn := range urns {
+		base := urn
+		if sep := createOpts.URNVersionSeparator; sep != "" {
+			if i := strings.LastIndex(urn, sep); i > 0 {
+				base = urn[:i]
+			}
+		}
+
+		if !resolved[urn] && !resolved[base] {
+			report(LintRuleUnresolvedURNs, LintSeverityError, "%s used in %s has no package from any source", urn, strings.Join(urnSections[urn], ", "))
+		}
+
+		if deprecation, exist := deprecated[urn]; exist {
+			report(LintRuleDeprecatedURNs, LintSeverityWarning, "%s is deprecated, replacement: %q, %s", urn, deprecation.Replacement, deprecation.Message)
+		}
+	}
+
+	return
+}
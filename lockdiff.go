@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+)
+
+// LockedPackageChange is the package of which revision is changed
+type LockedPackageChange struct {
+	URI         string `json:"uri"`
+	OldRevision string `json:"old_revision"`
+	NewRevision string `json:"new_revision"`
+}
+
+func (p LockedPackageChange) String() string {
+	return fmt.Sprintf("~ %s %s -> %s", p.URI, p.OldRevision, p.NewRevision)
+}
+
+// LockDiff is the packages difference between two lock files
+type LockDiff struct {
+	Added   []LockedPackage       `json:"added"`
+	Removed []LockedPackage       `json:"removed"`
+	Changed []LockedPackageChange `json:"changed"`
+}
+
+func (p LockDiff) Empty() bool {
+	return len(p.Added) == 0 && len(p.Removed) == 0 && len(p.Changed) == 0
+}
+
+// Lines returns the differences by line, ordered by added, removed and
+// changed, each in package uri order
+func (p LockDiff) Lines() (lines []string) {
+	for _, pkg := range p.Added {
+		lines = append(lines, fmt.Sprintf("+ %s %s", pkg.URI, pkg.Revision))
+	}
+
+	for _, pkg := range p.Removed {
+		lines = append(lines, fmt.Sprintf("- %s %s", pkg.URI, pkg.Revision))
+	}
+
+	for _, change := range p.Changed {
+		lines = append(lines, change.String())
+	}
+
+	return
+}
+
+// DiffLockFiles compares the packages of the new lock file with the old one
+func DiffLockFiles(oldLock, newLock LockFile) (diff LockDiff) {
+	oldRevs := map[string]string{}
+	for _, pkg := range oldLock.Packages {
+		oldRevs[pkg.URI] = pkg.Revision
+	}
+
+	newRevs := map[string]string{}
+	for _, pkg := range newLock.Packages {
+		newRevs[pkg.URI] = pkg.Revision
+	}
+
+	// the lock file packages are sorted by uri when saved
+	for _, pkg := range newLock.Packages {
+		oldRev, exist := oldRevs[pkg.URI]
+		if !exist {
+			diff.Added = append(diff.Added, pkg)
+		} else if oldRev != pkg.Revision {
+			diff.Changed = append(diff.Changed, LockedPackageChange{URI: pkg.URI, OldRevision: oldRev, NewRevision: pkg.Revision})
+		}
+	}
+
+	for _, pkg := range oldLock.Packages {
+		if _, exist := newRevs[pkg.URI]; !exist {
+			diff.Removed = append(diff.Removed, pkg)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLockFiles(t *testing.T) {
+	oldLock := LockFile{Packages: []LockedPackage{
+		{URI: "github.com/test/changed", Revision: "a1"},
+		{URI: "github.com/test/kept", Revision: "b1"},
+		{URI: "github.com/test/removed", Revision: "c1"},
+	}}
+
+	newLock := LockFile{Packages: []LockedPackage{
+		{URI: "github.com/test/added", Revision: "d1"},
+		{URI: "github.com/test/changed", Revision: "a2"},
+		{URI: "github.com/test/kept", Revision: "b1"},
+	}}
+
+	diff := DiffLockFiles(oldLock, newLock)
+
+	expected := []string{
+		"+ github.com/test/added d1",
+		"- github.com/test/removed c1",
+		"~ github.com/test/changed a1 -> a2",
+	}
+
+	if diff.Empty() || !reflect.DeepEqual(diff.Lines(), expected) {
+		t.Errorf("diff lines are %v, want %v", diff.Lines(), expected)
+	}
+
+	if diff = DiffLockFiles(newLock, newLock); !diff.Empty() {
+		t.Errorf("diff of the same lock file is %v, want empty", diff.Lines())
+	}
+
+	if diff = DiffLockFiles(LockFile{}, LockFile{}); !diff.Empty() {
+		t.Errorf("diff of empty lock files is %v, want empty", diff.Lines())
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LockFileName is the name of lock file written into project, it records
+// the packages and their revisions which the project was generated with
+const LockFileName = "spirit-packages.lock"
+
+type LockedPackage struct {
+	URI      string `json:"uri"`
+	Revision string `json:"revision"`
+}
+
+type LockFile struct {
+	Packages []LockedPackage `json:"packages"`
+}
+
+func LoadLockFile(filename string) (lock LockFile, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(data, &lock); err != nil {
+		return
+	}
+
+	return
+}
+
+func (p *LockFile) Save(filename string) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(p, "", "    "); err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(filename, data, os.FileMode(0644))
+
+	return
+}
+
+func (p *LockFile) Contains(uri string) bool {
+	for _, pkg := range p.Packages {
+		if pkg.URI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// newLockFile records the current revisions of packages, the revision is
+// read from the vcs checkout in GOPATH, or the requested revision if the
+// package is not checked out
+func newLockFile(packages []Package) (lock LockFile) {
+	for _, pkg := range packages {
+		revision := pkg.Revision
+		if out, err := execCommand("git -C " + path.Join(pkg.gosrc, pkg.URI) + " rev-parse HEAD"); err == nil {
+			revision = strings.TrimSpace(string(out))
+		}
+		lock.Packages = append(lock.Packages, LockedPackage{URI: pkg.URI, Revision: revision})
+	}
+
+	sort.Sort(lockedPackages(lock.Packages))
+
+	return
+}
+
+type lockedPackages []LockedPackage
+
+func (p lockedPackages) Len() int           { return len(p) }
+func (p lockedPackages) Less(i, j int) bool { return p[i].URI < p[j].URI }
+func (p lockedPackages) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// prunedDirName is the dir under GOPATH which the pruned packages are moved
+// into, it is out of GOPATH/src, so go tool does not see them
+const prunedDirName = "spirit-pruned"
+
+// prunePackages reports the packages of previous lock file which are no
+// longer referenced, and moves them out of GOPATH/src into GOPATH/spirit-pruned
+// if remove is true, since the projects not created by spirit-tool may still
+// import them. A package is kept if it is not the root of a vcs repository, if
+// it shares the path with a referenced package, or if lock file of another
+// project in GOPATH still records it. All the packages are decided and
+// reported before any of them is moved, and nothing is moved if any lock file
+// of GOPATH could not be loaded
+func (p *SpiritHelper) prunePackages(gosrc string, projectPath string, previous LockFile, remove bool) (err error) {
+	var unused []string
+	for _, locked := range previous.Packages {
+		referenced := false
+		for _, pkg := range p.RefPackages {
+			if pkg.URI == locked.URI {
+				referenced = true
+				break
+			}
+		}
+
+		if !referenced {
+			unused = append(unused, locked.URI)
+		}
+	}
+
+	if len(unused) == 0 {
+		return
+	}
+
+	if !remove {
+		for _, uri := range unused {
+			p.logger().Infof("package %s is no longer referenced", uri)
+		}
+		return
+	}
+
+	others, loadErr := otherLockFiles(gosrc, projectPath)
+	if loadErr != nil {
+		p.logger().Warnf("packages are not pruned, %s", loadErr)
+		return
+	}
+
+	var pruned []string
+	for _, uri := range unused {
+		if reason := keepPackageReason(uri, path.Join(gosrc, uri), p.RefPackages, others); reason != "" {
+			p.logger().Infof("package %s is no longer referenced, it is kept, %s", uri, reason)
+			continue
+		}
+
+		p.logger().Infof("package %s is no longer referenced, it is pruned", uri)
+		pruned = append(pruned, uri)
+	}
+
+	for _, uri := range pruned {
+		pkgPath := path.Join(gosrc, uri)
+		prunedPath := path.Join(path.Dir(gosrc), prunedDirName, uri)
+
+		// the package pruned before is replaced
+		if err = os.RemoveAll(prunedPath); err != nil {
+			return
+		}
+
+		if err = os.MkdirAll(path.Dir(prunedPath), os.FileMode(0755)); err != nil {
+			return
+		}
+
+		if err = os.Rename(pkgPath, prunedPath); err != nil {
+			return
+		}
+
+		p.logger().Infof("package %s is moved from %s to %s, remove it if no project needs it", uri, pkgPath, prunedPath)
+	}
+
+	return
+}
+
+func keepPackageReason(uri string, pkgPath string, refPackages []Package, others []LockFile) string {
+	if _, err := os.Stat(path.Join(pkgPath, ".git")); err != nil {
+		return "it is not the root of a git repository"
+	}
+
+	for _, pkg := range refPackages {
+		if strings.HasPrefix(pkg.URI+"/", uri+"/") || strings.HasPrefix(uri+"/", pkg.URI+"/") {
+			return "it shares the path with package " + pkg.URI
+		}
+	}
+
+	for _, other := range others {
+		for _, pkg := range other.Packages {
+			if strings.HasPrefix(pkg.URI+"/", uri+"/") {
+				return "it is used by other project"
+			}
+		}
+	}
+
+	return ""
+}
+
+// lockFileMaxDepth is the max depth of the project dirs under GOPATH/src which
+// otherLockFiles looks for lock files, e.g. github.com/org/repo/cmd/app
+const lockFileMaxDepth = 5
+
+// otherLockFiles loads the lock files of projects in GOPATH except the one
+// at projectPath, only the dirs within lockFileMaxDepth are scanned, and the
+// hidden, vendor and testdata dirs are skipped
+func otherLockFiles(gosrc string, projectPath string) (locks []LockFile, err error) {
+	gosrc = path.Clean(gosrc)
+
+	err = filepath.Walk(gosrc, func(filename string, info os.FileInfo, e error) error {
+		if e != nil {
+			return nil
+		}
+
+		if info.IsDir() {
+			if filename == gosrc {
+				return nil
+			}
+
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" ||
+				strings.Count(strings.TrimPrefix(filename, gosrc+"/"), "/") >= lockFileMaxDepth {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.Name() != LockFileName || path.Dir(filename) == path.Clean(projectPath) {
+			return nil
+		}
+
+		lock, e := LoadLockFile(filename)
+		if e != nil {
+			return fmt.Errorf("load lock file %s failed, %s", filename, e)
+		}
+
+		locks = append(locks, lock)
+
+		return nil
+	})
+
+	return
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestKeepPackageReason(t *testing.T) {
+	gosrc, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gosrc)
+
+	for _, dir := range []string{"github.com/test/b", "github.com/test/c/.git", "github.com/test/d/.git", "github.com/test/e/.git"} {
+		if err = os.MkdirAll(path.Join(gosrc, dir), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refPackages := []Package{{URI: "github.com/test/c/sub"}}
+
+	others := []LockFile{
+		{Packages: []LockedPackage{{URI: "github.com/test/d/sub"}}},
+	}
+
+	expected := map[string]string{
+		"github.com/test/b": "it is not the root of a git repository",
+		"github.com/test/c": "it shares the path with package github.com/test/c/sub",
+		"github.com/test/d": "it is used by other project",
+		"github.com/test/e": "",
+	}
+
+	for uri, reason := range expected {
+		if r := keepPackageReason(uri, path.Join(gosrc, uri), refPackages, others); r != reason {
+			t.Errorf("keep reason of package %s is %q, want %q", uri, r, reason)
+		}
+	}
+}
+
+func TestOtherLockFiles(t *testing.T) {
+	gosrc, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gosrc)
+
+	locks := map[string]string{
+		"github.com/test/project":         "github.com/test/project",
+		"github.com/test/other":           "github.com/test/other",
+		"github.com/test/other/cmd/app":   "github.com/test/app",
+		"github.com/test/other/cmd/app/x": "github.com/test/too-deep",
+		"github.com/test/.hidden":         "github.com/test/hidden",
+		"github.com/test/other/vendor/v":  "github.com/test/vendored",
+	}
+
+	for dir, uri := range locks {
+		lock := LockFile{Packages: []LockedPackage{{URI: uri}}}
+		if err = os.MkdirAll(path.Join(gosrc, dir), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err = lock.Save(path.Join(gosrc, dir, LockFileName)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	others, err := otherLockFiles(gosrc, path.Join(gosrc, "github.com/test/project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, lock := range others {
+		found[lock.Packages[0].URI] = true
+	}
+
+	if len(found) != 2 || !found["github.com/test/other"] || !found["github.com/test/app"] {
+		t.Errorf("lock files found are %v, want the ones of other and app", found)
+	}
+
+	if err = ioutil.WriteFile(path.Join(gosrc, "github.com/test/other", LockFileName), []byte("{"), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = otherLockFiles(gosrc, path.Join(gosrc, "github.com/test/project")); err == nil {
+		t.Errorf("the broken lock file is not reported")
+	}
+}
+
+func TestPrunePackagesDecidesBeforeMoving(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+
+	gosrc := path.Join(gopath, "src")
+	projectPath := path.Join(gosrc, "github.com/test/project")
+
+	for _, dir := range []string{"github.com/test/a/.git", "github.com/test/other"} {
+		if err = os.MkdirAll(path.Join(gosrc, dir), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the lock file of other project could not be loaded, so it is unknown
+	// whether it needs package a
+	if err = ioutil.WriteFile(path.Join(gosrc, "github.com/test/other", LockFileName), []byte("{"), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	helper := &SpiritHelper{warnings: &warnings}
+
+	previous := LockFile{Packages: []LockedPackage{{URI: "github.com/test/a"}}}
+
+	if err = helper.prunePackages(gosrc, projectPath, previous, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(path.Join(gosrc, "github.com/test/a")); err != nil {
+		t.Errorf("package is moved though the lock file of other project is broken")
+	}
+
+	if len(warnings) != 1 {
+		t.Errorf("warnings are %v, want the broken lock file", warnings)
+	}
+
+	if err = os.Remove(path.Join(gosrc, "github.com/test/other", LockFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = helper.prunePackages(gosrc, projectPath, previous, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(path.Join(gopath, prunedDirName, "github.com/test/a", ".git")); err != nil {
+		t.Errorf("package is not moved into %s: %s", prunedDirName, err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// teeLogger writes the logs into w with timestamp besides the logger, the
+// debug logs are always written
+type teeLogger struct {
+	Logger
+	w     io.Writer
+	mutex *sync.Mutex
+}
+
+func (p teeLogger) write(level string, format string, args ...interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	fmt.Fprintf(p.w, "%s [%s] %s\n", time.Now().Format(time.RFC3339), level, fmt.Sprintf(format, args...))
+}
+
+func (p teeLogger) Debugf(format string, args ...interface{}) {
+	p.write("debug", format, args...)
+	p.Logger.Debugf(format, args...)
+}
+
+func (p teeLogger) Infof(format string, args ...interface{}) {
+	p.write("info", format, args...)
+	p.Logger.Infof(format, args...)
+}
+
+func (p teeLogger) Warnf(format string, args ...interface{}) {
+	p.write("warning", format, args...)
+	p.Logger.Warnf(format, args...)
+}
+
+func (p teeLogger) Errorf(format string, args ...interface{}) {
+	p.write("error", format, args...)
+	p.Logger.Errorf(format, args...)
+}
+
+// openLogFile opens createOpts.LogFile for appending the logs of helper, the
+// returned closeLog writes the result error and closes it, it does nothing if
+// no log file is given or the log file is opened by the caller, e.g.
+// CreateProject in RunProject
+func (p *SpiritHelper) openLogFile(createOpts CreateOptions) (closeLog func(err error), err error) {
+	closeLog = func(error) {}
+
+	if createOpts.LogFile == "" || p.logFile != nil {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.OpenFile(createOpts.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.FileMode(0644)); err != nil {
+		err = fmt.Errorf("open log file %s failed, %s", createOpts.LogFile, err)
+		return
+	}
+
+	p.logFile = f
+	p.logMutex = &sync.Mutex{}
+
+	closeLog = func(err error) {
+		if err != nil {
+			teeLogger{w: f, mutex: p.logMutex}.write("error", "%s", err)
+		}
+		p.logFile = nil
+		f.Close()
+	}
+
+	return
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gogap/spirit"
+)
+
+// Logger is the logger used by SpiritHelper, *logrus.Logger satisfies it
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// warningRecorder records the warnings logged through it
+type warningRecorder struct {
+	Logger
+	warnings *[]string
+}
+
+func (p warningRecorder) Warnf(format string, args ...interface{}) {
+	*p.warnings = append(*p.warnings, fmt.Sprintf(format, args...))
+	p.Logger.Warnf(format, args...)
+}
+
+func (p *SpiritHelper) logger() Logger {
+	var logger Logger = p.Logger
+	if logger == nil {
+		logger = spirit.Logger()
+	}
+
+	if p.logFile != nil {
+		logger = teeLogger{Logger: logger, w: p.logFile, mutex: p.logMutex}
+	}
+
+	if p.warnings != nil {
+		return warningRecorder{Logger: logger, warnings: p.warnings}
+	}
+
+	return logger
+}
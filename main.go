@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
@@ -22,12 +23,15 @@ var (
 	verbosity = 0
 )
 
+// version of spirit-tool, it could be set by -ldflags "-X main.version=x.y.z"
+var version = "0.0.1"
+
 func main() {
 	app = cli.NewApp()
 	app.Name = "spirit-tool"
 	app.Authors = []cli.Author{{"zeal", "xujinzheng@gmail.com"}}
 	app.Usage = "help user easily to use spirit"
-	app.Version = "0.0.1"
+	app.Version = version
 
 	app.Commands = []cli.Command{
 		commandUpgrade(upgrade),
@@ -35,12 +39,25 @@ func main() {
 		commandRun(run),
 		commandCreate(create),
 		commandBuild(build),
+		commandDeps(deps),
+		commandPlan(plan),
+		commandBatch(batch),
+		commandNewTemplate(newTemplate),
+		commandTemplateData(templateData),
+		commandLint(lint),
+		commandLockDiff(lockDiff),
+		commandDoctor(doctor),
+		commandLintSource(lintSource),
+		commandUpdateConfig(updateConfig),
+		commandSummary(summary),
+		commandScanSource(scanSource),
+		commandHash(hash),
 	}
 
 	app.Run(os.Args)
 }
 
-func upgrade(context *cli.Context) {
+func initVerbosity(context *cli.Context) {
 	verbosity = context.Int("verbosity")
 	if !context.IsSet("verbosity") {
 		if verbosity < 2 {
@@ -48,6 +65,10 @@ func upgrade(context *cli.Context) {
 		}
 	}
 	spirit.Logger().Level = logrus.Level(verbosity)
+}
+
+func upgrade(context *cli.Context) {
+	initVerbosity(context)
 
 	var err error
 
@@ -86,35 +107,38 @@ func find(context *cli.Context) {
 	return
 }
 
-func create(context *cli.Context) {
-	verbosity = context.Int("verbosity")
-	if !context.IsSet("verbosity") {
-		if verbosity < 2 {
-			verbosity = 2
-		}
+// prepare loads the spirit config and the create options shared by the
+// create, run and build commands
+func prepare(context *cli.Context) (helper *SpiritHelper, createOpts CreateOptions, tmplArgs map[string]interface{}, err error) {
+	configFile := context.String("config")
+
+	if configFile == "" {
+		err = fmt.Errorf("please input config file")
+		return
 	}
 
-	spirit.Logger().Level = logrus.Level(verbosity)
+	if helper, createOpts, tmplArgs, err = newCreateOptions(context); err != nil {
+		return
+	}
 
-	var err error
+	if err = helper.LoadSpiritConfig(configFile); err != nil {
+		return
+	}
 
-	defer func() {
-		if err != nil {
-			spirit.Logger().Error(err)
-			os.Exit(128)
-		}
-	}()
+	return
+}
 
+// newCreateOptions creates the helper and the create options from the
+// project flags, the spirit config is not loaded
+func newCreateOptions(context *cli.Context) (helper *SpiritHelper, createOpts CreateOptions, tmplArgs map[string]interface{}, err error) {
 	goPath := context.String("gopath")
-	projectPath := context.String("path")
-	configFile := context.String("config")
 	extSources := context.StringSlice("source")
-	getPkg := context.Bool("get")
 	updatePkg := context.Bool("update")
 	strArgs := context.StringSlice("args")
-	forceWrite := context.Bool("force")
 	templateName := context.String("template")
 	revConfig := context.String("rev")
+	strExtraPkgs := context.StringSlice("package")
+	argsEnvPrefix := context.String("args-env-prefix")
 
 	if goPath == "" {
 		err = fmt.Errorf("could not get GOPATH")
@@ -123,24 +147,19 @@ func create(context *cli.Context) {
 
 	spirit.Logger().Infof("GOPATH: %s", goPath)
 
-	if projectPath == "" {
-		err = fmt.Errorf("please input your project path, like: github.com/your_orgs/project_name ")
-		return
-	}
-
-	if configFile == "" {
-		err = fmt.Errorf("please input config file")
-		return
+	sourceGoPath := context.String("source-gopath")
+	if sourceGoPath == "" {
+		sourceGoPath = goPath
 	}
 
 	sources := []string{
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/offical.json"),
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/third_party.json"),
+		path.Join(sourceGoPath, "src", "github.com/gogap/spirit-tool/source/offical.json"),
+		path.Join(sourceGoPath, "src", "github.com/gogap/spirit-tool/source/third_party.json"),
 	}
 
 	sources = append(sources, extSources...)
 
-	tmplArgs := map[string]interface{}{}
+	tmplArgs = map[string]interface{}{}
 
 	for _, arg := range strArgs {
 		arg = strings.TrimSpace(arg)
@@ -154,43 +173,236 @@ func create(context *cli.Context) {
 		}
 	}
 
-	helper := SpiritHelper{}
+	extraPkgs := map[string]string{}
 
-	if err = helper.LoadSpiritConfig(configFile); err != nil {
+	for _, pkg := range strExtraPkgs {
+		pkg = strings.TrimSpace(pkg)
+		if pkg != "" {
+			v := strings.Split(pkg, "=")
+			if len(v) > 2 {
+				err = fmt.Errorf("the extra package format error, package: %s", pkg)
+				return
+			}
+			extraPkgs[v[0]] = ""
+			if len(v) == 2 {
+				extraPkgs[v[0]] = v[1]
+			}
+		}
+	}
+
+	overrides := map[string]PackageOverride{}
+
+	for _, replace := range context.StringSlice("replace") {
+		replace = strings.TrimSpace(replace)
+		if replace != "" {
+			v := strings.Split(replace, "=")
+			if len(v) != 2 {
+				err = fmt.Errorf("the replace format error, replace: %s", replace)
+				return
+			}
+
+			override := PackageOverride{URI: v[1]}
+			if i := strings.LastIndex(v[1], "@"); i > 0 {
+				override.URI = v[1][:i]
+				override.Revision = v[1][i+1:]
+			}
+			overrides[v[0]] = override
+		}
+	}
+
+	packageEnvs := map[string][]string{}
+
+	for _, env := range context.StringSlice("package-env") {
+		env = strings.TrimSpace(env)
+		if env != "" {
+			v := strings.SplitN(env, "=", 2)
+			if len(v) != 2 || !strings.Contains(v[1], "=") {
+				err = fmt.Errorf("the package env format error, package-env: %s", env)
+				return
+			}
+			packageEnvs[v[0]] = append(packageEnvs[v[0]], v[1])
+		}
+	}
+
+	var entrypoints []Entrypoint
+	if entrypoints, err = parseEntrypoints(context.StringSlice("cmd")); err != nil {
 		return
 	}
 
-	var rev map[string]string
-	if revConfig != "" {
-		loadKeyValueJSON(revConfig, &rev)
+	var updateSince time.Time
+	if since := context.String("since"); since != "" {
+		if updateSince, err = time.Parse("2006-01-02", since); err != nil {
+			err = fmt.Errorf("the since format error, since: %s, %s", since, err)
+			return
+		}
+	}
+
+	var helperOpts []Option
+	if templateRoot := context.String("template-root"); templateRoot != "" {
+		helperOpts = append(helperOpts, WithTemplateRoot(templateRoot))
+	}
+
+	if timeout := context.Duration("config-timeout"); timeout > 0 {
+		helperOpts = append(helperOpts, WithHTTPTimeout(timeout))
+	}
+
+	if auth := context.String("config-auth"); auth != "" {
+		helperOpts = append(helperOpts, WithHTTPAuth(auth))
+	}
+
+	if context.Bool("strict-config") {
+		helperOpts = append(helperOpts, WithStrictConfig())
+	}
+
+	if format := context.String("config-format"); format != "" {
+		helperOpts = append(helperOpts, WithConfigFormat(format))
+	}
+
+	if helper, err = NewSpiritHelper(helperOpts...); err != nil {
+		return
 	}
 
-	createOpts := CreateOptions{
+	var deprecations map[string]URNDeprecation
+	if deprecationsFile := context.String("deprecations"); deprecationsFile != "" {
+		if deprecations, err = loadDeprecatedURNs(deprecationsFile); err != nil {
+			return
+		}
+	}
+
+	var resolvers []Resolver
+	for _, resolverURL := range context.StringSlice("resolver") {
+		resolvers = append(resolvers, &HTTPResolver{URL: resolverURL})
+	}
+
+	createOpts = CreateOptions{
 		TemplateName:     templateName,
 		GoPath:           goPath,
-		ProjectPath:      projectPath,
-		GetPackages:      getPkg,
+		SourceGoPath:     context.String("source-gopath"),
+		OutputGoPath:     context.String("output-gopath"),
 		UpdatePackages:   updatePkg,
-		ForceWrite:       forceWrite,
 		Sources:          sources,
-		PackagesRevision: nil,
+		RevisionFile:     revConfig,
+		ExtraPackages:    extraPkgs,
+		PackageOverrides: overrides,
+		DeprecatedURNs:   deprecations,
+		ArgsFiles:        context.StringSlice("args-file"),
+		ArgsEnvPrefix:    argsEnvPrefix,
+		GoProxy:          context.String("goproxy"),
+		FetchTimeout:     context.Duration("fetch-timeout"),
+		UpdateSince:      updateSince,
+		UseCheckedOut:    context.Bool("use-checkout"),
+		LogFile:          context.String("log-file"),
+		ServiceName:      context.String("service-name"),
+		Layout:           context.String("layout"),
+		StagingRoot:      context.String("staging-root"),
+		Entrypoints:      entrypoints,
+		PackageEnvs:      packageEnvs,
+		Vendor:           context.Bool("vendor"),
+		ConfigFileName:   context.String("config-name"),
+		NormalizeConfig:  context.Bool("normalize-config"),
+		TemplateConfig:   context.Bool("template-config"),
+
+		OutputConfigFormat: context.String("output-config-format"),
+		ConfigRuntimePath:  context.String("config-path"),
+		SelectedEntrypoint: context.String("bin"),
+
+		StrictURNContexts:   context.Bool("strict-urn"),
+		URNContextWhitelist: context.StringSlice("allow-urn"),
+		URNVersionSeparator: context.String("urn-version-sep"),
+		Resolvers:           resolvers,
+		AllowUnresolvedURNs: context.Bool("allow-unresolved"),
+		AllowEmpty:          context.Bool("allow-empty"),
+
+		VerifyURNRegistrations: context.Bool("verify-urn"),
+		WarningsAsErrors:       context.Bool("warnings-as-errors"),
+	}
+
+	return
+}
+
+func create(context *cli.Context) {
+	initVerbosity(context)
+
+	// only the result path is printed to stdout in quiet mode
+	quiet := context.Bool("quiet")
+	if quiet {
+		spirit.Logger().Level = logrus.ErrorLevel
+		spirit.Logger().Out = os.Stderr
+	}
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	projectPath := context.String("path")
+
+	if projectPath == "" {
+		err = fmt.Errorf("please input your project path, like: github.com/your_orgs/project_name ")
+		return
+	}
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+	var tmplArgs map[string]interface{}
+
+	if helper, createOpts, tmplArgs, err = prepare(context); err != nil {
+		return
+	}
+
+	createOpts.ProjectPath = projectPath
+	createOpts.GetPackages = context.Bool("get")
+	createOpts.ForceWrite = context.Bool("force")
+	createOpts.Preserve = context.StringSlice("preserve")
+	createOpts.OutputFileName = context.String("output-file")
+	createOpts.SystemdUnit = context.Bool("systemd")
+	createOpts.GenerateTests = context.Bool("tests")
+	createOpts.EmbedConfig = context.Bool("embed-config")
+	createOpts.GenerateGitignore = context.Bool("gitignore")
+	createOpts.WriteEffectiveOptions = context.Bool("write-options")
+	createOpts.SkipGeneratedHeader = context.Bool("no-header")
+	createOpts.SkipFormat = context.Bool("no-fmt")
+	createOpts.SkipSyntaxCheck = context.Bool("no-syntax-check")
+	createOpts.WriteLockFile = context.Bool("lock")
+	createOpts.PrunePackages = context.Bool("prune")
+	createOpts.VetGenerated = context.Bool("vet")
+	createOpts.Interactive = context.Bool("interactive")
+
+	defer func() {
+		for _, timing := range helper.Timings {
+			spirit.Logger().Debugf("phase %s took %s, failed: %v", timing.Phase, timing.Duration, timing.Failed)
+		}
+	}()
+
+	if context.Bool("build") {
+		var binPath string
+		if binPath, err = helper.BuildOnly(createOpts, tmplArgs); err != nil {
+			return
+		}
+		spirit.Logger().Infof("binary built at %s", binPath)
+		if quiet {
+			fmt.Println(binPath)
+		}
+		return
 	}
 
 	if err = helper.CreateProject(createOpts, tmplArgs); err != nil {
 		return
 	}
 
+	if quiet {
+		fmt.Println(createOpts.projectDir())
+	}
+
 	return
 }
 
 func run(context *cli.Context) {
-	verbosity = context.Int("verbosity")
-	if !context.IsSet("verbosity") {
-		if verbosity < 2 {
-			verbosity = 2
-		}
-	}
-	spirit.Logger().Level = logrus.Level(verbosity)
+	initVerbosity(context)
 
 	var err error
 
@@ -201,53 +413,96 @@ func run(context *cli.Context) {
 		}
 	}()
 
-	goPath := context.String("gopath")
-	configFile := context.String("config")
-	extSources := context.StringSlice("source")
-
-	updatePkg := context.Bool("update")
-	strArgs := context.StringSlice("args")
-	templateName := context.String("template")
-	revConfig := context.String("rev")
 	detach := context.Bool("detach")
 	envs := context.StringSlice("env")
 
-	if goPath == "" {
-		err = fmt.Errorf("could not get GOPATH")
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+	var tmplArgs map[string]interface{}
+
+	if helper, createOpts, tmplArgs, err = prepare(context); err != nil {
 		return
 	}
 
-	spirit.Logger().Infof("GOPATH: %s", goPath)
+	tmpDir := ""
+	if tmpDir, err = ioutil.TempDir("", "spirit-tool."); err != nil {
+		return
+	}
 
-	if configFile == "" {
-		err = fmt.Errorf("please input config file")
+	createOpts.ProjectPath = tmpDir
+	createOpts.GetPackages = true
+	createOpts.ForceWrite = true
+	createOpts.IsTempPath = true
+	createOpts.BinOutputDir = context.String("bin-dir")
+	createOpts.ProgramArgs = context.StringSlice("program-arg")
+	createOpts.VersionVarPath = context.String("version-var")
+
+	if profiles := context.String("build-profiles"); profiles != "" {
+		if createOpts.BuildProfiles, err = loadBuildProfiles(profiles); err != nil {
+			return
+		}
+	}
+	createOpts.SelectedProfile = context.String("profile")
+	createOpts.BuildTimeout = context.Duration("build-timeout")
+
+	if createOpts.Artifacts, err = parseArtifacts(context.StringSlice("artifact")); err != nil {
 		return
 	}
+	for _, name := range context.StringSlice("stop-signal") {
+		var sig os.Signal
+		if sig, err = parseSignal(name); err != nil {
+			return
+		}
+		createOpts.StopSignals = append(createOpts.StopSignals, sig)
+	}
 
-	sources := []string{
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/offical.json"),
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/third_party.json"),
+	if name := context.String("kill-signal"); name != "" {
+		if createOpts.KillSignal, err = parseSignal(name); err != nil {
+			return
+		}
 	}
 
-	sources = append(sources, extSources...)
+	createOpts.PreStop = context.String("pre-stop")
 
-	tmplArgs := map[string]interface{}{}
+	if addr := context.String("health-addr"); addr != "" {
+		createOpts.HealthCheck = &HealthCheckOptions{
+			Address: addr,
+			Path:    context.String("health-path"),
+			Timeout: context.Duration("health-timeout"),
+		}
+	}
 
-	for _, arg := range strArgs {
-		arg = strings.TrimSpace(arg)
-		if arg != "" {
-			v := strings.Split(arg, "=")
-			if len(v) != 2 {
-				err = fmt.Errorf("the args to template format error, arg: %s", arg)
-				return
-			}
-			tmplArgs[v[0]] = v[1]
+	var exitCode int
+	if exitCode, err = helper.RunProject(createOpts, detach, envs, tmplArgs); err != nil {
+		if _, ok := err.(*ExitError); ok && exitCode > 0 {
+			spirit.Logger().Error(err)
+			os.Exit(exitCode)
 		}
+		return
 	}
 
-	helper := SpiritHelper{}
+	return
+}
 
-	if err = helper.LoadSpiritConfig(configFile); err != nil {
+func build(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	output := context.String("output")
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+	var tmplArgs map[string]interface{}
+
+	if helper, createOpts, tmplArgs, err = prepare(context); err != nil {
 		return
 	}
 
@@ -256,38 +511,65 @@ func run(context *cli.Context) {
 		return
 	}
 
-	var rev map[string]string
-	if revConfig != "" {
-		loadKeyValueJSON(revConfig, &rev)
+	createOpts.ProjectPath = tmpDir
+	createOpts.GetPackages = true
+	createOpts.ForceWrite = true
+	createOpts.VersionVarPath = context.String("version-var")
+
+	if profiles := context.String("build-profiles"); profiles != "" {
+		if createOpts.BuildProfiles, err = loadBuildProfiles(profiles); err != nil {
+			return
+		}
+	}
+	createOpts.SelectedProfile = context.String("profile")
+	createOpts.BuildTimeout = context.Duration("build-timeout")
+
+	if createOpts.Artifacts, err = parseArtifacts(context.StringSlice("artifact")); err != nil {
+		return
 	}
 
-	createOpts := CreateOptions{
-		TemplateName:     templateName,
-		GoPath:           goPath,
-		ProjectPath:      tmpDir,
-		GetPackages:      true,
-		UpdatePackages:   updatePkg,
-		ForceWrite:       true,
-		Sources:          sources,
-		PackagesRevision: rev,
-		IsTempPath:       true,
+	if !path.IsAbs(output) {
+		fp, _ := filepath.Abs(os.Args[0])
+		output = path.Join(path.Dir(fp), output)
 	}
 
-	if err = helper.RunProject(createOpts, detach, envs, tmplArgs); err != nil {
+	if err = helper.BuildProject(createOpts, output, tmplArgs); err != nil {
 		return
 	}
 
 	return
 }
 
-func build(context *cli.Context) {
-	verbosity = context.Int("verbosity")
-	if !context.IsSet("verbosity") {
-		if verbosity < 2 {
-			verbosity = 2
+func deps(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
 		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+
+	if helper, createOpts, _, err = prepare(context); err != nil {
+		return
 	}
-	spirit.Logger().Level = logrus.Level(verbosity)
+
+	createOpts.GetPackages = context.Bool("get")
+
+	if err = helper.PrintDependencies(os.Stdout, createOpts, context.Bool("transitive")); err != nil {
+		return
+	}
+
+	return
+}
+
+func plan(context *cli.Context) {
+	initVerbosity(context)
 
 	var err error
 
@@ -298,94 +580,430 @@ func build(context *cli.Context) {
 		}
 	}()
 
-	goPath := context.String("gopath")
-	configFile := context.String("config")
-	extSources := context.StringSlice("source")
+	var helper *SpiritHelper
+	var createOpts CreateOptions
 
-	updatePkg := context.Bool("update")
-	strArgs := context.StringSlice("args")
-	templateName := context.String("template")
-	revConfig := context.String("rev")
-	output := context.String("output")
+	if helper, createOpts, _, err = prepare(context); err != nil {
+		return
+	}
+
+	if err = helper.PrintPlan(os.Stdout, createOpts); err != nil {
+		return
+	}
+
+	return
+}
+
+func batch(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
 
+	manifest := context.String("manifest")
+	if manifest == "" {
+		err = fmt.Errorf("please input manifest file")
+		return
+	}
+
+	var specs []ProjectSpec
+	var data []byte
+	if data, err = ioutil.ReadFile(manifest); err != nil {
+		return
+	} else if err = json.Unmarshal(data, &specs); err != nil {
+		return
+	}
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+
+	if helper, createOpts, _, err = newCreateOptions(context); err != nil {
+		return
+	}
+
+	createOpts.GetPackages = context.Bool("get")
+	createOpts.ForceWrite = context.Bool("force")
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if _, _, err = helper.GenerateAllContext(ctx, createOpts, specs, context.Int("concurrency")); err != nil {
+		return
+	}
+
+	return
+}
+
+func lint(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+
+	if helper, createOpts, _, err = prepare(context); err != nil {
+		return
+	}
+
+	createOpts.DisabledLintRules = context.StringSlice("disable")
+
+	var findings []LintFinding
+	if findings, err = helper.Lint(createOpts); err != nil {
+		return
+	}
+
+	failed := false
+	for _, finding := range findings {
+		fmt.Println(finding)
+		if finding.Severity == LintSeverityError {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+
+	return
+}
+
+func templateData(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+	var tmplArgs map[string]interface{}
+
+	if helper, createOpts, tmplArgs, err = prepare(context); err != nil {
+		return
+	}
+
+	createOpts.ProjectPath = context.String("path")
+
+	if err = helper.PrintTemplateData(os.Stdout, createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	return
+}
+
+func newTemplate(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	name := context.Args().First()
+	if name == "" {
+		err = fmt.Errorf("please input template name")
+		return
+	}
+
+	var helperOpts []Option
+	if templateRoot := context.String("template-root"); templateRoot != "" {
+		helperOpts = append(helperOpts, WithTemplateRoot(templateRoot))
+	}
+
+	var helper *SpiritHelper
+	if helper, err = NewSpiritHelper(helperOpts...); err != nil {
+		return
+	}
+
+	if _, err = helper.NewTemplate(context.String("gopath"), name, context.Bool("force")); err != nil {
+		return
+	}
+
+	return
+}
+
+func hash(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+	var tmplArgs map[string]interface{}
+
+	if helper, createOpts, tmplArgs, err = prepare(context); err != nil {
+		return
+	}
+
+	createOpts.ProjectPath = context.String("path")
+	createOpts.OutputFileName = context.String("output-file")
+
+	var contentHash string
+	if contentHash, err = helper.ContentHash(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	fmt.Println(contentHash)
+
+	return
+}
+
+func scanSource(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	goPath := context.String("gopath")
 	if goPath == "" {
 		err = fmt.Errorf("could not get GOPATH")
 		return
 	}
 
-	spirit.Logger().Infof("GOPATH: %s", goPath)
-
-	if configFile == "" {
-		err = fmt.Errorf("please input config file")
+	var sourceConf SourceConfig
+	var problems []string
+	if sourceConf, problems, err = ScanSource(path.Join(goPath, "src"), context.Args()...); err != nil {
 		return
 	}
 
-	sources := []string{
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/offical.json"),
-		path.Join(goPath, "src", "github.com/gogap/spirit-tool/source/third_party.json"),
+	for _, problem := range problems {
+		spirit.Logger().Warn(problem)
 	}
 
-	sources = append(sources, extSources...)
+	var data []byte
+	if data, err = json.MarshalIndent(sourceConf, "", "    "); err != nil {
+		return
+	}
 
-	tmplArgs := map[string]interface{}{}
+	fmt.Println(string(data))
 
-	for _, arg := range strArgs {
-		arg = strings.TrimSpace(arg)
-		if arg != "" {
-			v := strings.Split(arg, "=")
-			if len(v) != 2 {
-				err = fmt.Errorf("the args to template format error, arg: %s", arg)
-				return
-			}
-			tmplArgs[v[0]] = v[1]
+	return
+}
+
+func summary(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+
+	if helper, _, _, err = prepare(context); err != nil {
+		return
+	}
+
+	for _, section := range helper.Summary() {
+		if section.Actors > 0 || context.Bool("all") {
+			fmt.Println(section)
 		}
 	}
 
-	helper := SpiritHelper{}
+	return
+}
 
-	if err = helper.LoadSpiritConfig(configFile); err != nil {
+func updateConfig(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+
+	if helper, createOpts, _, err = prepare(context); err != nil {
 		return
 	}
 
-	tmpDir := ""
-	if tmpDir, err = ioutil.TempDir("", "spirit-tool."); err != nil {
+	createOpts.ProjectPath = context.String("path")
+	createOpts.OutputFileName = context.String("output-file")
+	createOpts.EmbedConfig = context.Bool("embed-config")
+	createOpts.Preserve = context.StringSlice("preserve")
+
+	if err = helper.UpdateConfig(createOpts, context.Int("pid")); err != nil {
 		return
 	}
 
-	var rev map[string]string
-	if revConfig != "" {
-		loadKeyValueJSON(revConfig, &rev)
+	return
+}
+
+func lintSource(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	if len(context.Args()) == 0 {
+		err = fmt.Errorf("please input source files")
+		return
 	}
 
-	createOpts := CreateOptions{
-		TemplateName:     templateName,
-		GoPath:           goPath,
-		ProjectPath:      tmpDir,
-		GetPackages:      true,
-		UpdatePackages:   updatePkg,
-		ForceWrite:       true,
-		Sources:          sources,
-		PackagesRevision: rev,
+	failed := false
+	for _, filename := range context.Args() {
+		var findings []LintFinding
+		if findings, err = ValidateSourceConfig(filename); err != nil {
+			return
+		}
+
+		for _, finding := range findings {
+			fmt.Println(finding)
+			if finding.Severity == LintSeverityError {
+				failed = true
+			}
+		}
 	}
 
-	if !path.IsAbs(output) {
-		fp, _ := filepath.Abs(os.Args[0])
-		output = path.Join(path.Dir(fp), output)
+	if failed {
+		os.Exit(1)
 	}
 
-	if err = helper.BuildProject(createOpts, output, tmplArgs); err != nil {
+	return
+}
+
+func doctor(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	var helper *SpiritHelper
+	var createOpts CreateOptions
+
+	if helper, createOpts, _, err = newCreateOptions(context); err != nil {
 		return
 	}
 
+	createOpts.GetPackages = true
+	createOpts.SkipFormat = context.Bool("no-fmt")
+
+	failed := false
+	for _, check := range helper.CheckTools(createOpts) {
+		fmt.Println(check)
+		if check.Err != nil {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+
 	return
 }
 
-func loadKeyValueJSON(filename string, v *map[string]string) (err error) {
-	var revData []byte
-	if revData, err = ioutil.ReadFile(filename); err != nil {
+func lockDiff(context *cli.Context) {
+	initVerbosity(context)
+
+	var err error
+
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	if len(context.Args()) != 2 {
+		err = fmt.Errorf("please input the old and new lock files")
 		return
-	} else if err = json.Unmarshal(revData, v); err != nil {
+	}
+
+	var oldLock, newLock LockFile
+	if oldLock, err = LoadLockFile(context.Args()[0]); err != nil {
 		return
 	}
+
+	if newLock, err = LoadLockFile(context.Args()[1]); err != nil {
+		return
+	}
+
+	diff := DiffLockFiles(oldLock, newLock)
+
+	for _, line := range diff.Lines() {
+		fmt.Println(line)
+	}
+
+	if !diff.Empty() && !context.Bool("report") {
+		os.Exit(1)
+	}
+
+	return
+}
+
+// parseArtifacts parses the artifacts of format src=dst
+func parseArtifacts(values []string) (artifacts []Artifact, err error) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		v := strings.Split(value, "=")
+		if len(v) != 2 || v[0] == "" || v[1] == "" {
+			err = fmt.Errorf("the artifact format error, artifact: %s", value)
+			return
+		}
+
+		artifacts = append(artifacts, Artifact{Src: v[0], Dst: v[1]})
+	}
 	return
 }
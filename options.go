@@ -2,6 +2,15 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogap/spirit"
 )
 
 var (
@@ -10,6 +19,12 @@ var (
 	ErrNoTemplateName    = errors.New("no template name")
 )
 
+// DefaultTemplateName is used when CreateOptions.TemplateName is empty
+var DefaultTemplateName = "classic"
+
+// defaultTemplateRoot is the dir of templates relative to GOPATH/src
+const defaultTemplateRoot = "github.com/gogap/spirit-tool/template"
+
 type CreateOptions struct {
 	TemplateName     string
 	GoPath           string
@@ -20,6 +35,288 @@ type CreateOptions struct {
 	Sources          []string
 	PackagesRevision map[string]string
 	IsTempPath       bool
+
+	// SourceGoPath is the GOPATH of templates and default sources,
+	// OutputGoPath is the GOPATH which the relative ProjectPath is under,
+	// both default to GoPath, the packages are always fetched into GoPath,
+	// so the project is built and vetted with GOPATH of OutputGoPath:GoPath
+	SourceGoPath string
+	OutputGoPath string
+
+	// RevisionFile is a json or yaml file of package uri to revision, the
+	// revisions of PackagesRevision win over it
+	RevisionFile string
+
+	// ExtraPackages are packages not referenced by any urn but required by
+	// the template, key is the package uri and value is the revision
+	ExtraPackages map[string]string
+
+	// PackageOverrides replaces the packages resolved from sources, key is
+	// the original package uri, both the fetched package and the imports of
+	// generated code use the replacement
+	PackageOverrides map[string]PackageOverride
+
+	// URNVersionSeparator separates the version suffix of urn, e.g. # of
+	// urn:foo#v2, the versioned urn is resolved by the exact urn of sources
+	// first, then by the base urn, empty means exact match only
+	URNVersionSeparator string
+
+	// AllowUnresolvedURNs warns about the urns not resolved instead of
+	// failing, their packages are skipped and a TODO comment listing them
+	// is added to the generated code
+	AllowUnresolvedURNs bool
+
+	// AllowEmpty allows the config which references no urn, it is an error
+	// by default, as the project would do nothing
+	AllowEmpty bool
+
+	// Resolvers resolve the urns not in sources, in order, e.g. HTTPResolver
+	// of a urn registry service
+	Resolvers []Resolver `json:"-"`
+
+	// Vendor makes the project a module and fetches the packages into its
+	// vendor dir instead of GOPATH, the project is built with -mod=vendor
+	Vendor bool
+
+	// PackagesHook is called with the resolved packages before they are
+	// fetched, the returned packages are fetched and passed into template,
+	// e.g. to filter out the vendored packages or pin revisions
+	PackagesHook func(packages []Package) ([]Package, error) `json:"-"`
+
+	// WarningsAsErrors makes CreateProject fail with the warnings logged
+	// during it, e.g. deprecated urns or overwriting the project
+	WarningsAsErrors bool
+
+	// DisabledLintRules are the rules skipped by Lint
+	DisabledLintRules []string
+
+	// DeprecatedURNs are warned about if referenced by config, they win over
+	// the deprecated urns of sources
+	DeprecatedURNs map[string]URNDeprecation
+
+	// ArgsFiles are json files of template args, they are merged in order
+	// and the later one wins
+	ArgsFiles []string
+
+	// ArgsEnvPrefix enables reading template args from environment
+	// variables, e.g. with prefix SPIRIT_ARG_ the variable
+	// SPIRIT_ARG_service_name=foo sets args.service_name to foo.
+	// Precedence from low to high: template args.json, ArgsFiles, the args
+	// passed into CreateProject, the environment variables
+	ArgsEnvPrefix string
+
+	// Interactive prompts on stdin for the args referenced by template but
+	// not given, otherwise the missing args are an error
+	Interactive bool
+
+	// LazyArgs resolve the args referenced by template but not given, each
+	// is called only if its key is missing, before Interactive prompts
+	LazyArgs map[string]func() (interface{}, error) `json:"-"`
+
+	// StopSignals are forwarded to the running project by RunProject,
+	// default is SIGTERM, interrupt and SIGQUIT of tty reach the project
+	// directly, so they are ignored by RunProject unless listed here, and
+	// if listed they are forwarded too, so the project started from a tty
+	// receives them twice
+	StopSignals []os.Signal
+	// KillSignal kills the running project immediately, nil disables it,
+	// default is nil
+	KillSignal os.Signal
+
+	// PreStop is the shell command run in project dir on the first stop
+	// signal before it is forwarded, e.g. to deregister from the load
+	// balancer, the pid of project is $SPIRIT_PID, the kill signal skips it
+	PreStop string
+
+	// SkipGeneratedHeader disables the `Code generated ... DO NOT EDIT.`
+	// header of the generated main.go
+	SkipGeneratedHeader bool
+
+	// LogFile is the file which the logs of creating, fetching, building and
+	// running are appended into with timestamp, besides the console
+	LogFile string
+
+	// SkipFormat disables go fmt of the generated code, e.g. if goimports
+	// is run later anyway
+	SkipFormat bool
+
+	// SkipSyntaxCheck disables parsing the rendered go files in memory
+	// before they are written, which reports the template bugs without
+	// the go toolchain
+	SkipSyntaxCheck bool
+
+	// CreateTime is the create_time of template, e.g. for reproducible
+	// output, zero means SOURCE_DATE_EPOCH of environment if it is set,
+	// otherwise now
+	CreateTime time.Time
+
+	// GoProxy is the GOPROXY list used to fetch packages, default is GOPROXY
+	// of the environment, if it has any proxy the packages of GOPATH mode are
+	// fetched through it in module mode and copied into GOPATH, the direct in
+	// the list falls back to vcs, empty or direct only fetches from vcs
+	GoProxy string
+
+	// PackageEnvs are the environment variables of fetching the packages,
+	// key is the uri prefix, only the longest matched prefix is applied, e.g.
+	// {"git.internal.com": ["GOFLAGS=-insecure"]}, they are set after the
+	// GOPROXY and GO111MODULE of module mode, so they win over GoProxy
+	PackageEnvs map[string][]string
+
+	// FetchTimeout limits the time of fetching each package, zero means no
+	// limit
+	FetchTimeout time.Duration
+
+	// UpdateSince limits UpdatePackages to the packages which have upstream
+	// commits after it, the others are kept at the current revision, zero
+	// means all, it is ignored if packages are fetched through GOPROXY
+	UpdateSince time.Time
+
+	// UseCheckedOut keeps the packages which are already checked out in
+	// GOPATH, e.g. git submodules, at their HEAD instead of fetching, the
+	// HEAD is recorded in the lock file if it is written
+	UseCheckedOut bool
+
+	// HealthCheck makes RunProject wait for the launched project to be
+	// healthy, the project is killed if it is not healthy before timeout
+	HealthCheck *HealthCheckOptions
+
+	// ProgramArgs are the args which RunProject launches the project with
+	ProgramArgs []string
+
+	// OnStart is called by RunProject with the pid of the launched project
+	OnStart func(pid int) `json:"-"`
+
+	// OnStop is called by RunProject when the launched project exits or is
+	// killed, the exit code is -1 if it is unknown
+	OnStop func(exitCode int, err error) `json:"-"`
+
+	// ConfigFileName is the file name of the config copied into project,
+	// default is the file name of the loaded config, it is a base name and
+	// its extension should be the format of the copied config
+	ConfigFileName string
+
+	// ConfigRuntimePath is the path the generated code loads the config
+	// from when deployed, relative to the working dir of binary, default is
+	// the config file name copied into project
+	ConfigRuntimePath string
+
+	// TemplateConfig renders the loaded config as template with the delims
+	// of code template before it is parsed, the data is {"args": args},
+	// e.g. "port": //<-.args.port->//
+	TemplateConfig bool
+
+	// NormalizeConfig writes the json config copied into project re-encoded
+	// as indented json, the json5 config is always normalized into json
+	// unless OutputConfigFormat is set
+	NormalizeConfig bool
+
+	// ConfigTransform returns the config copied into project from the loaded
+	// config and its encoded data, e.g. to strip secrets, the result is also
+	// the config_content of template
+	ConfigTransform func(conf spirit.SpiritConfig, data []byte) ([]byte, error) `json:"-"`
+
+	// OutputConfigFormat transcodes the config copied into project to json,
+	// yaml or toml, the extension of config file name follows it, empty
+	// keeps the json config as is and transcodes the others to json, the
+	// format should be listed in the config_formats arg of template, which
+	// is default json
+	OutputConfigFormat string
+
+	// WriteLockFile writes the packages and their revisions into the lock
+	// file of project, the packages of the previous lock file which are no
+	// longer referenced are reported
+	WriteLockFile bool
+
+	// PrunePackages moves the packages which are recorded in the lock file
+	// of the existing project but no longer referenced out of GOPATH/src into
+	// GOPATH/spirit-pruned, the packages still used by other projects of
+	// spirit-tool in GOPATH are kept, it writes the lock file as well
+	PrunePackages bool
+
+	// OutputFileName is the file the template is rendered into, default is
+	// main.go, any other name, e.g. spirit_gen.go with template inject,
+	// generates into an existing project and leaves its main.go untouched
+	OutputFileName string
+
+	// WriteEffectiveOptions writes the create options with the defaults and
+	// the values of environment filled in into project as spirit-options.json
+	WriteEffectiveOptions bool
+
+	// GenerateGitignore writes a .gitignore into project, which ignores the
+	// built binary under BinOutputDir, the vendor dir and the os files
+	GenerateGitignore bool
+
+	// EmbedConfig writes the config copied into project as []byte var
+	// embeddedConfig of package main, so the binary needs no config file,
+	// e.g. with template embedded
+	EmbedConfig bool
+
+	// Entrypoints are the binaries of project, the template of each is
+	// rendered instead of the template of project, SelectedEntrypoint is
+	// the one built and run, default is the first
+	Entrypoints        []Entrypoint
+	SelectedEntrypoint string
+
+	// GenerateTests writes a test into project which checks the shipped
+	// config is parsed and its urns are resolved when generated
+	GenerateTests bool
+
+	// SystemdUnit writes a systemd unit ServiceName.service into project,
+	// ServiceName is default the base name of project
+	SystemdUnit bool
+	ServiceName string
+
+	// Layout stages the built project into StagingRoot for packaging, e.g.
+	// LayoutFHS, empty means no staging, ServiceName names the files and
+	// the config path of generated code defaults to the one of layout
+	Layout string
+
+	// StagingRoot is the dir relative to project which the layout is staged
+	// into, default is staging
+	StagingRoot string
+
+	// Preserve are the paths or globs relative to project of the files
+	// which are never overwritten, even with ForceWrite
+	Preserve []string
+
+	// DryRun makes GetPackages only report what would be fetched and updated
+	DryRun bool
+
+	// VerifyURNRegistrations warns about the urns not found in the source of
+	// their packages after fetching, it is a best-effort check
+	VerifyURNRegistrations bool
+
+	// VetGenerated runs `go vet` in the generated project and returns the
+	// findings as error
+	VetGenerated bool
+
+	// StrictURNContexts fails if one urn is used in more than one kind of
+	// config section, except the urns in URNContextWhitelist
+	StrictURNContexts   bool
+	URNContextWhitelist []string
+
+	// BinOutputDir is the dir relative to project path which the binary is
+	// built into, default is the project path
+	BinOutputDir string
+
+	// VersionVarPath is the import path of package, e.g.: main, in which the
+	// string vars BuildTime, BuildConfig, BuildToolVersion and BuildRevisions
+	// are set by -ldflags -X when building project
+	VersionVarPath string
+
+	// BuildTimeout limits the time of `go build`, the build is killed when
+	// it expires, zero means no limit, the running project is not limited
+	BuildTimeout time.Duration
+
+	// Artifacts are copied after the project is built successfully
+	Artifacts []Artifact
+
+	// BuildProfiles are the named build settings, SelectedProfile is applied
+	// by BuildProject, it must be one of BuildProfiles
+	BuildProfiles   map[string]BuildProfile
+	SelectedProfile string
+
+	templateRoot string
 }
 
 func (p *CreateOptions) Validate() (err error) {
@@ -33,14 +330,327 @@ func (p *CreateOptions) Validate() (err error) {
 		return
 	}
 
+	if p.TemplateName == "" {
+		p.TemplateName = DefaultTemplateName
+	}
+
 	if p.TemplateName == "" {
 		err = ErrNoTemplateName
 		return
 	}
 
+	if _, e := os.Stat(path.Join(p.templateDir(), "main.go")); e != nil {
+		err = fmt.Errorf("template %s not found, %s", p.TemplateName, e)
+		return
+	}
+
+	if p.ConfigFileName != "" && !isBaseName(p.ConfigFileName) {
+		err = fmt.Errorf("config file name %s should be a file name without dir", p.ConfigFileName)
+		return
+	}
+
+	switch p.OutputConfigFormat {
+	case "", ConfigFormatJSON, ConfigFormatYAML, ConfigFormatTOML:
+	default:
+		err = fmt.Errorf("unknown output config format: %s", p.OutputConfigFormat)
+		return
+	}
+
+	switch p.Layout {
+	case "", LayoutFHS:
+	default:
+		err = fmt.Errorf("unknown layout: %s", p.Layout)
+		return
+	}
+
+	if err = p.validateVendor(); err != nil {
+		return
+	}
+
+	if p.EmbedConfig && len(p.Entrypoints) > 0 {
+		err = ErrEmbedConfigWithEntrypoints
+		return
+	}
+
+	if err = p.validateEntrypoints(); err != nil {
+		return
+	}
+
+	for _, proxy := range goProxyList(p.GoProxy) {
+		if proxy != "direct" && proxy != "off" && !strings.Contains(proxy, "://") {
+			err = fmt.Errorf("the goproxy format error, proxy: %s", proxy)
+			return
+		}
+	}
+
+	if err = p.checkProjectInGoSrc(); err != nil {
+		return
+	}
+
+	if p.SelectedProfile != "" {
+		if _, exist := p.BuildProfiles[p.SelectedProfile]; !exist {
+			err = fmt.Errorf("build profile %s not found", p.SelectedProfile)
+			return
+		}
+	}
+
+	return
+}
+
+// isSubPath reports whether the abs target is under the abs dir
+func isSubPath(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+func (p *CreateOptions) templateDir() string {
+	if p.templateRoot != "" {
+		return path.Join(p.templateRoot, p.TemplateName)
+	}
+	return path.Join(p.sourceGoPath(), "src", defaultTemplateRoot, p.TemplateName)
+}
+
+// sourceGoPath returns the GOPATH of templates and default sources
+func (p *CreateOptions) sourceGoPath() string {
+	if p.SourceGoPath != "" {
+		return p.SourceGoPath
+	}
+	return p.GoPath
+}
+
+// outputGoPath returns the GOPATH which the relative ProjectPath is under
+func (p *CreateOptions) outputGoPath() string {
+	if p.OutputGoPath != "" {
+		return p.OutputGoPath
+	}
+	return p.GoPath
+}
+
+// buildEnvs returns the environment of building and vetting the project, in
+// GOPATH mode the project under OutputGoPath imports the packages fetched into
+// GoPath, so GOPATH has both of them
+func (p *CreateOptions) buildEnvs() (envs []string) {
+	if p.Vendor {
+		return
+	}
+
+	goPath := p.outputGoPath()
+	if goPath != p.GoPath {
+		goPath += string(os.PathListSeparator) + p.GoPath
+	}
+
+	return []string{"GOPATH=" + goPath, "GO111MODULE=off"}
+}
+
+// createTime returns the create time passed into template
+func (p *CreateOptions) createTime() (t time.Time, err error) {
+	if !p.CreateTime.IsZero() {
+		return p.CreateTime, nil
+	}
+
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+
+	var seconds int64
+	if seconds, err = strconv.ParseInt(epoch, 10, 64); err != nil {
+		err = fmt.Errorf("the SOURCE_DATE_EPOCH format error, SOURCE_DATE_EPOCH: %s, %s", epoch, err)
+		return
+	}
+
+	t = time.Unix(seconds, 0).UTC()
+
+	return
+}
+
+// goProxy returns the GOPROXY list which the packages are fetched through,
+// GoProxy or GOPROXY of environment
+func (p *CreateOptions) goProxy() string {
+	if p.GoProxy != "" {
+		return p.GoProxy
+	}
+
+	return os.Getenv("GOPROXY")
+}
+
+// packagesProxy returns the GOPROXY list which the packages of GOPATH mode
+// are fetched through in module mode, it is empty if the list has no proxy
+// but direct or off, then they are fetched from vcs by `go get`
+func (p *CreateOptions) packagesProxy() string {
+	for _, proxy := range goProxyList(p.goProxy()) {
+		if proxy != "direct" && proxy != "off" {
+			return p.goProxy()
+		}
+	}
+	return ""
+}
+
+// fetchesFromVCS reports whether the packages may be fetched from vcs, that
+// is the GOPROXY list is empty, direct only or falls back to direct, which
+// is the default of go if GOPROXY is empty
+func (p *CreateOptions) fetchesFromVCS() bool {
+	if !p.Vendor && p.packagesProxy() == "" {
+		return true
+	}
+
+	proxies := goProxyList(p.goProxy())
+	if len(proxies) == 0 {
+		return true
+	}
+
+	for _, proxy := range proxies {
+		if proxy == "direct" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBaseName reports whether name is a file name without dir, which is
+// written in the dir it is joined to
+func isBaseName(name string) bool {
+	return path.Base(name) == name && name != "." && name != ".." && !strings.Contains(name, "\\")
+}
+
+// goProxyList splits the GOPROXY list, the proxies are separated by comma or
+// pipe, which only differ in the fallback of go
+func goProxyList(goProxy string) (proxies []string) {
+	for _, proxy := range strings.FieldsFunc(goProxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return
+}
+
+// checkGoSrc makes sure GOPATH/src exists, it is created if the packages are
+// going to be fetched, or it returns an error explaining the GOPATH layout
+func (p *CreateOptions) checkGoSrc() (err error) {
+	goSrc := path.Join(p.GoPath, "src")
+
+	if fi, e := os.Stat(goSrc); e == nil {
+		if !fi.IsDir() {
+			err = fmt.Errorf("%s of GOPATH is not a directory", goSrc)
+		}
+		return
+	} else if !os.IsNotExist(e) {
+		err = e
+		return
+	}
+
+	if p.GetPackages {
+		return os.MkdirAll(goSrc, os.FileMode(0755))
+	}
+
+	err = fmt.Errorf("%s not exist, spirit-tool resolves sources and packages in GOPATH %s, set --gopath to your GOPATH, or use --get to create it and fetch packages", goSrc, p.GoPath)
+
+	return
+}
+
+// checkProjectInGoSrc makes sure the project is under OutputGoPath/src in
+// GOPATH mode, otherwise its import path is unknown, the vendor mode project
+// is a module and the temp project, by IsTempPath or in the temp dir, is never
+// imported
+func (p *CreateOptions) checkProjectInGoSrc() (err error) {
+	if p.Vendor || p.IsTempPath {
+		return
+	}
+
+	goSrc, e := filepath.Abs(path.Join(p.outputGoPath(), "src"))
+	if e != nil {
+		return e
+	}
+
+	projectDir, e := filepath.Abs(p.projectDir())
+	if e != nil {
+		return e
+	}
+
+	if tempDir, e := filepath.Abs(os.TempDir()); e == nil && isSubPath(tempDir, projectDir) {
+		return
+	}
+
+	if !isSubPath(goSrc, projectDir) {
+		err = fmt.Errorf("project path %s is not under %s, the import path of project is unknown in GOPATH mode, create it under GOPATH/src or use --vendor to make it a module", projectDir, goSrc)
+		return
+	}
+
 	return
 }
 
+// projectDir returns the absolute path of project, the relative ProjectPath
+// is under OutputGoPath/src
+func (p *CreateOptions) projectDir() string {
+	if path.IsAbs(p.ProjectPath) {
+		return p.ProjectPath
+	}
+	return path.Join(p.outputGoPath(), "src", p.ProjectPath)
+}
+
+// binaryPath returns the path of binary to build, the relative name is under
+// BinOutputDir of project
+func (p *CreateOptions) binaryPath(name string) string {
+	if path.IsAbs(name) {
+		return name
+	}
+	return path.Join(p.projectDir(), p.BinOutputDir, name)
+}
+
+// packageEnvs returns the PackageEnvs of the longest uri prefix matches uri
+func (p *CreateOptions) packageEnvs(uri string) (envs []string) {
+	matched := ""
+	for prefix := range p.PackageEnvs {
+		base := strings.TrimSuffix(prefix, "/")
+		if (uri == base || strings.HasPrefix(uri, base+"/")) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+
+	if matched == "" {
+		return
+	}
+
+	return p.PackageEnvs[matched]
+}
+
+// configRuntimePath returns the config path of the deployed project
+func (p *CreateOptions) configRuntimePath(configFileName string) string {
+	if p.ConfigRuntimePath != "" {
+		return p.ConfigRuntimePath
+	}
+	if p.Layout == LayoutFHS {
+		return path.Join(p.fhsConfigDir(), configFileName)
+	}
+	return configFileName
+}
+
+// outputFileName returns the file name the template is rendered into
+func (p *CreateOptions) outputFileName() string {
+	if p.OutputFileName != "" {
+		return p.OutputFileName
+	}
+	return "main.go"
+}
+
+// isPreserved reports whether the file name relative to project matches
+// Preserve, the glob without slash matches the base name too
+func (p *CreateOptions) isPreserved(name string) bool {
+	for _, pattern := range p.Preserve {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, path.Base(name)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type ProjectOptions struct {
 	IsInnerConfig     bool
 	DefaultConfigName string
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGoProxyList(t *testing.T) {
+	cases := map[string][]string{
+		"":                                  nil,
+		"direct":                            {"direct"},
+		"https://proxy.golang.org,direct":   {"https://proxy.golang.org", "direct"},
+		"https://a.com|https://b.com, off ": {"https://a.com", "https://b.com", "off"},
+		",,https://a.com,":                  {"https://a.com"},
+	}
+
+	for goProxy, expected := range cases {
+		if proxies := goProxyList(goProxy); !reflect.DeepEqual(proxies, expected) {
+			t.Errorf("proxy list of %q is %v, want %v", goProxy, proxies, expected)
+		}
+	}
+}
+
+func TestPackagesProxy(t *testing.T) {
+	defer os.Setenv("GOPROXY", os.Getenv("GOPROXY"))
+	os.Setenv("GOPROXY", "")
+
+	cases := map[string]string{
+		"":                          "",
+		"direct":                    "",
+		"off":                       "",
+		"direct,off":                "",
+		"https://a.com":             "https://a.com",
+		"https://a.com,direct":      "https://a.com,direct",
+		"direct|https://a.com|off ": "direct|https://a.com|off ",
+	}
+
+	for goProxy, expected := range cases {
+		createOpts := CreateOptions{GoProxy: goProxy}
+		if proxy := createOpts.packagesProxy(); proxy != expected {
+			t.Errorf("packages proxy of %q is %q, want %q", goProxy, proxy, expected)
+		}
+	}
+
+	os.Setenv("GOPROXY", "https://env.com")
+
+	createOpts := CreateOptions{}
+	if proxy := createOpts.packagesProxy(); proxy != "https://env.com" {
+		t.Errorf("packages proxy is %q, want GOPROXY of environment", proxy)
+	}
+}
+
+func TestIsBaseName(t *testing.T) {
+	cases := map[string]bool{
+		"config.json":       true,
+		".config.yaml":      true,
+		"":                  false,
+		".":                 false,
+		"..":                false,
+		"conf/config.json":  false,
+		"../config.json":    false,
+		"/config.json":      false,
+		"config.json/":      false,
+		"conf\\config.json": false,
+	}
+
+	for name, expected := range cases {
+		if isBaseName(name) != expected {
+			t.Errorf("isBaseName(%q) is %v, want %v", name, !expected, expected)
+		}
+	}
+}
+
+func TestPackageEnvs(t *testing.T) {
+	createOpts := CreateOptions{
+		PackageEnvs: map[string][]string{
+			"git.internal.com":          {"GOFLAGS=-insecure"},
+			"git.internal.com/team/":    {"GIT_TERMINAL_PROMPT=0"},
+			"git.internal.com/team/app": {"GOPROXY=direct"},
+		},
+	}
+
+	cases := map[string][]string{
+		"git.internal.com":              {"GOFLAGS=-insecure"},
+		"git.internal.com/other":        {"GOFLAGS=-insecure"},
+		"git.internal.com/team":         {"GIT_TERMINAL_PROMPT=0"},
+		"git.internal.com/team/lib":     {"GIT_TERMINAL_PROMPT=0"},
+		"git.internal.com/team/app":     {"GOPROXY=direct"},
+		"git.internal.com/team/app/sub": {"GOPROXY=direct"},
+		"git.internal.com/team/apps":    {"GIT_TERMINAL_PROMPT=0"},
+		"git.internal.company.com/x":    nil,
+		"github.com/gogap/spirit":       nil,
+	}
+
+	for uri, expected := range cases {
+		if envs := createOpts.packageEnvs(uri); !reflect.DeepEqual(envs, expected) {
+			t.Errorf("envs of package %s are %v, want %v", uri, envs, expected)
+		}
+	}
+}
+
+func TestCheckProjectInGoSrc(t *testing.T) {
+	cases := []struct {
+		createOpts CreateOptions
+		inGoSrc    bool
+	}{
+		{CreateOptions{GoPath: "/gopath", ProjectPath: "github.com/a/b"}, true},
+		{CreateOptions{GoPath: "/gopath", ProjectPath: "/gopath/src/github.com/a/b"}, true},
+		{CreateOptions{GoPath: "/gopath", ProjectPath: "/project"}, false},
+		{CreateOptions{GoPath: "/gopath", OutputGoPath: "/output", ProjectPath: "/gopath/src/github.com/a/b"}, false},
+		{CreateOptions{GoPath: "/gopath", ProjectPath: "/project", Vendor: true}, true},
+		{CreateOptions{GoPath: "/gopath", ProjectPath: "/project", IsTempPath: true}, true},
+		{CreateOptions{GoPath: "/gopath", ProjectPath: filepath.Join(os.TempDir(), "project")}, true},
+	}
+
+	for _, c := range cases {
+		if err := c.createOpts.checkProjectInGoSrc(); (err == nil) != c.inGoSrc {
+			t.Errorf("project %s with output gopath %s, vendor %v, temp %v, error: %v", c.createOpts.ProjectPath, c.createOpts.outputGoPath(), c.createOpts.Vendor, c.createOpts.IsTempPath, err)
+		}
+	}
+}
@@ -1,24 +1,85 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gogap/spirit"
 )
 
 type Package struct {
 	gosrc    string
+	proxy    string
+	timeout  time.Duration
+	since    time.Time
+	envs     []string
+	ctx      context.Context
+	logger   Logger
+	URI      string
+	Revision string
+}
+
+// packagesByURI sorts packages by uri, so the imports rendered from them are
+// in the same order for the same config
+type packagesByURI []Package
+
+func (p packagesByURI) Len() int           { return len(p) }
+func (p packagesByURI) Less(i, j int) bool { return p[i].URI < p[j].URI }
+func (p packagesByURI) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// PackageOverride replaces a package resolved from sources, like the
+// replace directive of go.mod
+type PackageOverride struct {
 	URI      string
 	Revision string
 }
 
 func (p *Package) Get(update bool) (err error) {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("fetch package %s timeout after %s", p.URI, time.Since(start))
+		}
+	}()
+
+	if p.proxy != "" {
+		return p.getByProxy(ctx, update)
+	}
 
 	baseCMD := "go get "
 	if verbosity > 0 {
 		baseCMD = "go get -v "
 	}
 
+	if update && !p.since.IsZero() {
+		if update, err = p.hasCommitsSince(ctx); err != nil {
+			return
+		}
+
+		if !update {
+			p.log().Infof("package %s has no commits since %s, it is not updated", p.URI, p.since.Format("2006-01-02"))
+		}
+	}
+
 	cmd := baseCMD + p.URI
 	if update {
 		cmd = baseCMD + "-u " + p.URI
@@ -26,8 +87,8 @@ func (p *Package) Get(update bool) (err error) {
 
 	var out []byte
 
-	if out, err = execCommand(cmd); err != nil {
-		spirit.Logger().Errorln(string(out))
+	if out, err = execCommandContext(ctx, cmd, "", p.envs); err != nil {
+		p.log().Errorf("%s", out)
 		return
 	}
 
@@ -37,10 +98,170 @@ func (p *Package) Get(update bool) (err error) {
 
 	checkoutCMD := "git -C " + path.Join(p.gosrc, p.URI) + " checkout " + p.Revision
 
-	if out, err = execCommand(checkoutCMD); err != nil {
-		spirit.Logger().Errorln(string(out))
+	if out, err = execCommandContext(ctx, checkoutCMD, "", nil); err != nil {
+		p.log().Errorf("%s", out)
+		return
+	}
+
+	return
+}
+
+// getByProxy fetches the package through GOPROXY in a temp module, module mode
+// resolves the revision itself, then the modules of the package and its deps
+// are copied into GOPATH, the dir exists is kept unless update or revision
+func (p *Package) getByProxy(ctx context.Context, update bool) (err error) {
+	if _, e := os.Stat(path.Join(p.gosrc, p.URI)); e == nil && !update && p.Revision == "" {
+		return
+	}
+
+	var modDir string
+	if modDir, err = ioutil.TempDir("", "spirit-tool.mod."); err != nil {
+		return
+	}
+	defer os.RemoveAll(modDir)
+
+	version := "latest"
+	if p.Revision != "" {
+		version = p.Revision
+	}
+
+	getArgs := []string{"get"}
+	if verbosity > 0 {
+		getArgs = append(getArgs, "-v")
+	}
+
+	envs := append([]string{"GOPROXY=" + p.proxy, "GO111MODULE=on", "GOFLAGS=-mod=mod"}, p.envs...)
+
+	var out []byte
+
+	for _, args := range [][]string{
+		{"mod", "init", "spirit-tool-fetch"},
+		append(getArgs, p.URI+"@"+version),
+		{"list", "-deps", "-f", "{{with .Module}}{{if not .Main}}{{.Path}}={{.Dir}}{{end}}{{end}}", p.URI},
+	} {
+		if out, err = execCommandArgsContext(ctx, modDir, envs, "go", args...); err != nil {
+			p.log().Errorf("%s", out)
+			return
+		}
+	}
+
+	modules := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if parts := strings.SplitN(strings.TrimSpace(line), "=", 2); len(parts) == 2 && path.IsAbs(parts[1]) {
+			modules[parts[0]] = parts[1]
+		}
+	}
+
+	var modPaths []string
+	for modPath := range modules {
+		modPaths = append(modPaths, modPath)
+	}
+
+	// the parent module is copied before the nested ones, which it replaces
+	sort.Strings(modPaths)
+
+	for _, modPath := range modPaths {
+		if err = p.copyModule(modules[modPath], path.Join(p.gosrc, modPath)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// copyModule replaces dst in GOPATH by the module dir of module cache, the
+// files are made writable, the git checkout of dst is kept as it is
+func (p *Package) copyModule(src, dst string) (err error) {
+	if _, e := os.Stat(path.Join(dst, ".git")); e == nil {
+		p.log().Infof("module %s is a git checkout, it is not replaced by the module of GOPROXY", dst)
 		return
 	}
 
+	if err = os.RemoveAll(dst); err != nil {
+		return
+	}
+
+	return filepath.Walk(src, func(filename string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+
+		target := path.Join(dst, strings.TrimPrefix(filename, src))
+
+		if info.IsDir() {
+			return os.MkdirAll(target, os.FileMode(0755))
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if e = copyFile(filename, target); e != nil {
+			return e
+		}
+
+		return os.Chmod(target, info.Mode().Perm()|0200)
+	})
+}
+
+// checkedOutRevision returns the HEAD of the package checkout in GOPATH, e.g.
+// a git submodule, ok is false if the package dir is not the root of a git
+// working tree
+func checkedOutRevision(pkg *Package) (revision string, ok bool) {
+	pkgPath := path.Clean(path.Join(pkg.gosrc, pkg.URI))
+
+	out, err := execCommand("git -C " + pkgPath + " rev-parse --show-toplevel")
+	if err != nil || path.Clean(strings.TrimSpace(string(out))) != pkgPath {
+		return
+	}
+
+	if out, err = execCommand("git -C " + pkgPath + " rev-parse HEAD"); err != nil {
+		return
+	}
+
+	return strings.TrimSpace(string(out)), true
+}
+
+// hasCommitsSince reports whether the upstream of the checkout has commits
+// after p.since, the package not checked out yet is always fetched
+func (p *Package) hasCommitsSince(ctx context.Context) (has bool, err error) {
+	pkgPath := path.Join(p.gosrc, p.URI)
+
+	if _, e := os.Stat(pkgPath); e != nil {
+		return true, nil
+	}
+
+	var out []byte
+	if out, err = execCommandContext(ctx, "git -C "+pkgPath+" fetch --quiet", "", p.envs); err != nil {
+		p.log().Errorf("%s", out)
+		return
+	}
+
+	// the checkout without upstream branch, e.g. detached at revision, is
+	// checked by the commits of all remote branches
+	logCMD := "git -C " + pkgPath + " log -1 --format=%ct @{upstream}"
+	if out, err = execCommandContext(ctx, logCMD, "", nil); err != nil {
+		logCMD = "git -C " + pkgPath + " log -1 --format=%ct --remotes"
+		if out, err = execCommandContext(ctx, logCMD, "", nil); err != nil {
+			p.log().Errorf("%s", out)
+			return
+		}
+	}
+
+	var commitTime int64
+	if commitTime, err = strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err != nil {
+		err = fmt.Errorf("read the latest commit time of package %s failed, %s", p.URI, err)
+		return
+	}
+
+	has = time.Unix(commitTime, 0).After(p.since)
+
 	return
 }
+
+func (p *Package) log() Logger {
+	if p.logger == nil {
+		return spirit.Logger()
+	}
+	return p.logger
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+)
+
+// writeProxyModule writes the module into dir as a GOPROXY of file://
+func writeProxyModule(dir, modPath, version string, files map[string]string) (err error) {
+	verDir := path.Join(dir, modPath, "@v")
+	if err = os.MkdirAll(verDir, os.FileMode(0755)); err != nil {
+		return
+	}
+
+	modFile := "module " + modPath + "\n"
+
+	meta := map[string]string{
+		"list":            version + "\n",
+		version + ".info": `{"Version":"` + version + `"}`,
+		version + ".mod":  modFile,
+	}
+
+	for name, data := range meta {
+		if err = ioutil.WriteFile(path.Join(verDir, name), []byte(data), os.FileMode(0644)); err != nil {
+			return
+		}
+	}
+
+	var f *os.File
+	if f, err = os.Create(path.Join(verDir, version+".zip")); err != nil {
+		return
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+
+	files["go.mod"] = modFile
+	for name, data := range files {
+		var fw io.Writer
+		if fw, err = w.Create(modPath + "@" + version + "/" + name); err != nil {
+			return
+		}
+		if _, err = fw.Write([]byte(data)); err != nil {
+			return
+		}
+	}
+
+	return w.Close()
+}
+
+func TestGetPackageByProxy(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	proxyDir := path.Join(dir, "proxy")
+	modCache := path.Join(dir, "modcache")
+	gosrc := path.Join(dir, "gopath", "src")
+
+	envs := []string{"GOMODCACHE=" + modCache, "GOSUMDB=off", "GOFLAGS=-mod=mod"}
+
+	// the module cache is read only
+	defer execCommandArgs("", envs, "go", "clean", "-modcache")
+
+	if err = writeProxyModule(proxyDir, "example.com/lib", "v1.0.0", map[string]string{
+		"lib.go":        "package lib\n",
+		"sub/sub.go":    "package sub\n\nimport _ \"example.com/dep\"\n",
+		"sub/README.md": "sub",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = writeProxyModule(proxyDir, "example.com/dep", "v1.2.0", map[string]string{
+		"dep.go": "package dep\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lib module requires no dep, so go get resolves the latest one
+	pkg := Package{
+		gosrc:    gosrc,
+		proxy:    "file://" + proxyDir,
+		envs:     envs,
+		URI:      "example.com/lib/sub",
+		Revision: "v1.0.0",
+	}
+
+	if err = pkg.Get(false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"example.com/lib/lib.go", "example.com/lib/sub/sub.go", "example.com/lib/sub/README.md", "example.com/dep/dep.go"} {
+		fi, e := os.Stat(path.Join(gosrc, name))
+		if e != nil {
+			t.Errorf("%s is not copied into GOPATH: %s", name, e)
+			continue
+		}
+		if fi.Mode().Perm()&0200 == 0 {
+			t.Errorf("%s copied into GOPATH is not writable", name)
+		}
+	}
+
+	// the git checkout in GOPATH is kept
+	if err = os.MkdirAll(path.Join(gosrc, "example.com", "dep", ".git"), os.FileMode(0755)); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(path.Join(gosrc, "example.com", "dep", "local.go"), []byte("package dep\n"), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pkg.Get(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(path.Join(gosrc, "example.com", "dep", "local.go")); err != nil {
+		t.Errorf("git checkout in GOPATH is replaced by the module of GOPROXY")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// PackagePlan reports what GetPackages would do with a package
+type PackagePlan struct {
+	URI             string
+	Present         bool
+	CurrentRevision string
+	TargetRevision  string
+	WillFetch       bool
+	WillUpdate      bool
+}
+
+func (p PackagePlan) String() string {
+	action := "keep"
+	if p.WillFetch {
+		action = "fetch"
+	} else if p.WillUpdate {
+		action = "update"
+	}
+
+	current := p.CurrentRevision
+	if current == "" {
+		current = "-"
+	}
+
+	target := p.TargetRevision
+	if target == "" {
+		target = "default"
+	}
+
+	return fmt.Sprintf("%-6s %s current: %s target: %s", action, p.URI, current, target)
+}
+
+// PlanPackages reports what GetPackages would do with each package without
+// fetching anything, the revision is resolved the same as GetPackages
+func (p *SpiritHelper) PlanPackages(createOpts CreateOptions) (plans []PackagePlan, err error) {
+	gosrc := path.Join(createOpts.GoPath, "src")
+
+	var pkgRevision map[string]string
+	if pkgRevision, err = createOpts.packagesRevision(); err != nil {
+		return
+	}
+
+	targets := map[string]string{}
+	var uris []string
+
+	for _, pkg := range p.RefPackages {
+		if _, exist := targets[pkg.URI]; !exist {
+			uris = append(uris, pkg.URI)
+		}
+		targets[pkg.URI] = pkg.Revision
+	}
+
+	for uri, revision := range pkgRevision {
+		if _, exist := targets[uri]; !exist {
+			uris = append(uris, uri)
+		}
+		targets[uri] = revision
+	}
+
+	for _, uri := range uris {
+		plan := PackagePlan{URI: uri, TargetRevision: targets[uri]}
+		pkgPath := path.Join(gosrc, uri)
+
+		if _, e := os.Stat(pkgPath); e == nil {
+			plan.Present = true
+		}
+
+		if !plan.Present {
+			plan.WillFetch = true
+			plans = append(plans, plan)
+			continue
+		}
+
+		if out, e := execCommand("git -C " + pkgPath + " rev-parse HEAD"); e == nil {
+			plan.CurrentRevision = strings.TrimSpace(string(out))
+		}
+
+		if createOpts.UpdatePackages {
+			plan.WillUpdate = true
+		} else if plan.TargetRevision != "" {
+			target := plan.TargetRevision
+			if out, e := execCommand("git -C " + pkgPath + " rev-parse " + target + "^{commit}"); e == nil {
+				target = strings.TrimSpace(string(out))
+			}
+			plan.WillUpdate = target != plan.CurrentRevision
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return
+}
+
+// PrintPlan writes the plan of fetching packages for createOpts to w
+func (p *SpiritHelper) PrintPlan(w io.Writer, createOpts CreateOptions) (err error) {
+	if createOpts.GoPath == "" {
+		err = ErrGoPathIsEmpty
+		return
+	}
+
+	goSrc := path.Join(createOpts.GoPath, "src")
+
+	if err = p.parse(goSrc, createOpts); err != nil {
+		return
+	}
+
+	p.appendExtraPackages(goSrc, createOpts.ExtraPackages)
+
+	if err = p.applyPackagesHook(goSrc, createOpts); err != nil {
+		return
+	}
+
+	var plans []PackagePlan
+	if plans, err = p.PlanPackages(createOpts); err != nil {
+		return
+	}
+
+	for _, plan := range plans {
+		fmt.Fprintln(w, plan)
+	}
+
+	return
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ToolCheck is the check result of an external tool which spirit-tool runs
+type ToolCheck struct {
+	Name       string
+	Path       string
+	Version    string
+	MinVersion string
+	Err        error
+}
+
+func (p ToolCheck) String() string {
+	if p.Err != nil {
+		return fmt.Sprintf("[FAIL] %s: %s", p.Name, p.Err)
+	}
+	return fmt.Sprintf("[OK] %s %s: %s", p.Name, p.Version, p.Path)
+}
+
+// goModuleMinVersion is the go version of module mode, which is used by
+// GOPROXY fetching and vendor mode
+const goModuleMinVersion = "1.11"
+
+var toolVersionRegexp = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// CheckTools checks the external tools required by createOpts are installed:
+// go, git if the packages are fetched by vcs, gofmt if the code is formatted
+func (p *SpiritHelper) CheckTools(createOpts CreateOptions) (checks []ToolCheck) {
+	goMinVersion := ""
+	if createOpts.Vendor || createOpts.packagesProxy() != "" {
+		goMinVersion = goModuleMinVersion
+	}
+
+	checks = append(checks, checkTool("go", goMinVersion, "version"))
+
+	if (createOpts.GetPackages || createOpts.Vendor) && createOpts.fetchesFromVCS() {
+		checks = append(checks, checkTool("git", "", "--version"))
+	}
+
+	// go fmt runs the gofmt of GOROOT, which may be not in PATH
+	if !createOpts.SkipFormat {
+		check := checkTool("gofmt", "", "")
+		if check.Err != nil {
+			if out, e := exec.Command("go", "env", "GOROOT").Output(); e == nil {
+				gofmtPath := filepath.Join(strings.TrimSpace(string(out)), "bin", "gofmt")
+				if _, e = os.Stat(gofmtPath); e == nil {
+					check.Path, check.Err = gofmtPath, nil
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	return
+}
+
+// Preflight returns an error listing the missing or too old external tools
+// required by createOpts, see CheckTools
+func (p *SpiritHelper) Preflight(createOpts CreateOptions) (err error) {
+	var failed []string
+	for _, check := range p.CheckTools(createOpts) {
+		if check.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Err))
+		}
+	}
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("required tools are not ready, %s", strings.Join(failed, "; "))
+		return
+	}
+
+	return
+}
+
+// checkTool looks up the tool in PATH, its version is read by versionArg if
+// it is not empty
+func checkTool(name string, minVersion string, versionArg string) (check ToolCheck) {
+	check.Name = name
+	check.MinVersion = minVersion
+
+	if check.Path, check.Err = exec.LookPath(name); check.Err != nil {
+		check.Err = fmt.Errorf("not found in PATH")
+		return
+	}
+
+	if versionArg == "" {
+		return
+	}
+
+	out, e := exec.Command(check.Path, versionArg).CombinedOutput()
+	if e != nil {
+		check.Err = fmt.Errorf("get version failed, %s", e)
+		return
+	}
+
+	check.Version = toolVersionRegexp.FindString(string(out))
+
+	if minVersion != "" && compareVersion(check.Version, minVersion) < 0 {
+		check.Err = fmt.Errorf("version %s is lower than %s", check.Version, minVersion)
+		return
+	}
+
+	return
+}
+
+// compareVersion compares the dotted numeric versions, e.g. 1.9 < 1.11
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
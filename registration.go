@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// verifyURNRegistrations warns about the urns which could not be found in
+// the source of their packages, it is a heuristic that the package registers
+// the urn by a string literal, so a missing urn usually means a typo in
+// sources or a package version which does not provide it
+func (p *SpiritHelper) verifyURNRegistrations(gosrc string) {
+	var urns []string
+	for urn := range p.urnPackages {
+		urns = append(urns, urn)
+	}
+	sort.Strings(urns)
+
+	sources := map[string]string{}
+
+	for _, urn := range urns {
+		pkg := p.urnPackages[urn]
+
+		src, exist := sources[pkg]
+		if !exist {
+			var err error
+			if src, err = readPackageSource(path.Join(gosrc, pkg)); err != nil {
+				p.logger().Warnf("could not verify urn %s, read package %s failed, %s", urn, pkg, err)
+				continue
+			}
+			sources[pkg] = src
+		}
+
+		if !strings.Contains(src, strconv.Quote(urn)) {
+			p.logger().Warnf("urn %s is not found in package %s, it may not be registered by the package", urn, pkg)
+		}
+	}
+}
+
+// readPackageSource returns the content of non-test go files of the package
+// dir
+func readPackageSource(dir string) (src string, err error) {
+	var files []string
+	if files, err = filepath.Glob(path.Join(dir, "*.go")); err != nil {
+		return
+	}
+
+	if len(files) == 0 {
+		err = os.ErrNotExist
+		return
+	}
+
+	var contents []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		var data []byte
+		if data, err = ioutil.ReadFile(file); err != nil {
+			return
+		}
+		contents = append(contents, string(data))
+	}
+
+	src = strings.Join(contents, "\n")
+
+	return
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultHTTPTimeout is the timeout of fetching remote config
+const defaultHTTPTimeout = 30 * time.Second
+
+func isRemoteConfig(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+// fetchRemoteConfig gets the config by http GET, name is the last element of
+// url path
+func (p *SpiritHelper) fetchRemoteConfig(rawurl string) (data []byte, name string, err error) {
+	var u *url.URL
+	if u, err = url.Parse(rawurl); err != nil {
+		return
+	}
+
+	if name = path.Base(u.Path); name == "/" || name == "." {
+		err = fmt.Errorf("no file name in config url: %s", rawurl)
+		return
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequest("GET", rawurl, nil); err != nil {
+		return
+	}
+
+	if p.httpAuth != "" {
+		req.Header.Set("Authorization", p.httpAuth)
+	}
+
+	timeout := p.httpTimeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("fetch config %s failed, status: %s", rawurl, resp.Status)
+		return
+	}
+
+	if data, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,33 @@
+package main
+
+// IsURNResolvable reports whether urn is resolved to a package the same as
+// CreateProject does with createOpts: by createOpts.Sources and the sources
+// added by AddSource, the base urn of URNVersionSeparator, the Resolvers and
+// the PackageOverrides, the duplicate urns of sources are error. The dirs of
+// sources are walked as CreateProject, the parsed source files are cached by
+// the helper until Reset
+func (p *SpiritHelper) IsURNResolvable(createOpts CreateOptions, urn string) (pkg string, ok bool, err error) {
+	var files []string
+	if files, err = p.expandSources(createOpts.Sources); err != nil {
+		return
+	}
+
+	if p.sourceCache == nil {
+		p.sourceCache = map[string]SourceConfig{}
+	}
+
+	var sources []urnSource
+	if sources, err = p.urnSources(files, p.sourceCache); err != nil {
+		return
+	}
+
+	var urnPkgs map[string]string
+	if _, urnPkgs, _, err = urnsToPackages("", []string{urn}, createOpts.PackageOverrides, createOpts.URNVersionSeparator, createOpts.Resolvers, sources); err != nil {
+		if _, unresolved := err.(*URNNotResolvedError); unresolved {
+			err = nil
+		}
+		return
+	}
+
+	return urnPkgs[urn], true, nil
+}
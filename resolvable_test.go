@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// mapResolver resolves the urns of the map
+type mapResolver map[string]string
+
+func (p mapResolver) Resolve(urn string) (pkg string, revision string, ok bool, err error) {
+	pkg, ok = p[urn]
+	return
+}
+
+func TestIsURNResolvable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := `{"packages": [
+		{"urn": "urn:x", "pkg": "github.com/test/x"},
+		{"urn": "urn:x#v3", "pkg": "github.com/test/x/v3"},
+		{"urn": "urn:old", "pkg": "github.com/test/old"}
+	]}`
+
+	if err = ioutil.WriteFile(path.Join(dir, "source.json"), []byte(source), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+
+	helper := &SpiritHelper{}
+
+	createOpts := CreateOptions{
+		Sources:             []string{dir},
+		URNVersionSeparator: "#",
+		Resolvers:           []Resolver{mapResolver{"urn:remote": "github.com/test/remote"}},
+		PackageOverrides: map[string]PackageOverride{
+			"github.com/test/old": {URI: "github.com/fork/old"},
+		},
+	}
+
+	cases := []struct {
+		urn string
+		pkg string
+		ok  bool
+	}{
+		{"urn:x", "github.com/test/x", true},
+		{"urn:x#v2", "github.com/test/x", true},
+		{"urn:x#v3", "github.com/test/x/v3", true},
+		{"urn:remote", "github.com/test/remote", true},
+		{"urn:old", "github.com/fork/old", true},
+		{"urn:y", "", false},
+		{"urn:y#v2", "", false},
+	}
+
+	for _, c := range cases {
+		pkg, ok, err := helper.IsURNResolvable(createOpts, c.urn)
+		if err != nil {
+			t.Errorf("resolve %s failed: %s", c.urn, err)
+			continue
+		}
+
+		if pkg != c.pkg || ok != c.ok {
+			t.Errorf("urn %s is resolved to %q, %v, want %q, %v", c.urn, pkg, ok, c.pkg, c.ok)
+		}
+	}
+
+	helper.AddSource(SourceConfig{Packages: []URNPackage{{URN: "urn:x", Pkg: "github.com/other/x"}}})
+
+	if _, _, err = helper.IsURNResolvable(createOpts, "urn:y"); err == nil {
+		t.Errorf("the duplicate urn of sources is not reported")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Resolver resolves urn to the package uri and its optional revision, ok is
+// false if the urn is unknown to the resolver
+type Resolver interface {
+	Resolve(urn string) (pkg string, revision string, ok bool, err error)
+}
+
+// sourceResolver is the default resolver of the urn packages of sources
+type sourceResolver map[string]string
+
+func (p sourceResolver) Resolve(urn string) (pkg string, revision string, ok bool, err error) {
+	pkg, ok = p[urn]
+	return
+}
+
+// HTTPResolver resolves urn by http GET of URL with query urn, the response
+// is json, e.g.: {"pkg": "github.com/gogap/spirit", "revision": "master"},
+// status 404 means the urn is unknown
+type HTTPResolver struct {
+	URL     string
+	Auth    string
+	Timeout time.Duration
+}
+
+func (p *HTTPResolver) Resolve(urn string) (pkg string, revision string, ok bool, err error) {
+	var u *url.URL
+	if u, err = url.Parse(p.URL); err != nil {
+		return
+	}
+
+	query := u.Query()
+	query.Set("urn", urn)
+	u.RawQuery = query.Encode()
+
+	var req *http.Request
+	if req, err = http.NewRequest("GET", u.String(), nil); err != nil {
+		return
+	}
+
+	if p.Auth != "" {
+		req.Header.Set("Authorization", p.Auth)
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("resolve urn %s by %s failed, status: %s", urn, p.URL, resp.Status)
+		return
+	}
+
+	var data []byte
+	if data, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	result := struct {
+		Pkg      string `json:"pkg"`
+		Revision string `json:"revision"`
+	}{}
+
+	if err = json.Unmarshal(data, &result); err != nil {
+		err = fmt.Errorf("resolve urn %s by %s failed, %s", urn, p.URL, err)
+		return
+	}
+
+	if result.Pkg == "" {
+		return
+	}
+
+	return result.Pkg, result.Revision, true, nil
+}
+
+// resolveURN resolves urn by the resolvers in order, the first one knows the
+// urn wins
+func resolveURN(resolvers []Resolver, urn string) (pkg string, revision string, ok bool, err error) {
+	for _, resolver := range resolvers {
+		if pkg, revision, ok, err = resolver.Resolve(urn); err != nil || ok {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// loadRevisionFile reads the map of package uri to revision from json or
+// yaml file, the format is detected by the file extension
+func loadRevisionFile(filename string) (revisions map[string]string, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &revisions)
+	default:
+		err = json.Unmarshal(data, &revisions)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("parse revision file %s failed, %s", filename, err)
+		return
+	}
+
+	return
+}
+
+// packagesRevision returns the revisions of RevisionFile merged with
+// PackagesRevision, PackagesRevision wins
+func (p *CreateOptions) packagesRevision() (revisions map[string]string, err error) {
+	if p.RevisionFile == "" {
+		revisions = p.PackagesRevision
+		return
+	}
+
+	if revisions, err = loadRevisionFile(p.RevisionFile); err != nil {
+		return
+	}
+
+	if revisions == nil {
+		revisions = map[string]string{}
+	}
+
+	for uri, revision := range p.PackagesRevision {
+		revisions[uri] = revision
+	}
+
+	return
+}
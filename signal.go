@@ -5,24 +5,113 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
-	"sync"
+	"strings"
 )
 
-var interrupted = make(chan struct{})
-var subProcExited = make(chan struct{})
+// waitSignal waits for the sub process to exit and returns the result of
+// cmder.Wait(), the stop signals received by spirit-tool are forwarded to the
+// sub process, and the kill signal kills it immediately, the signals of tty
+// such as INT and QUIT already reach the sub process in the same process
+// group, so they are ignored unless configured, a configured tty signal is
+// forwarded as well, so the sub process receives it twice from the tty
+func waitSignal(cmder *exec.Cmd, stopSignals []os.Signal, killSignal os.Signal, preStop func()) (err error) {
+	if stopSignals == nil {
+		stopSignals = defaultStopSignals
+	}
 
-func processSignals() {
-	sig := make(chan os.Signal)
-	signal.Notify(sig, signalsToIgnore...)
+	signals := append([]os.Signal{}, stopSignals...)
+	if killSignal != nil {
+		signals = append(signals, killSignal)
+	}
+
+	var ignores []os.Signal
+	for _, s := range signalsToIgnore {
+		if !containsSignal(signals, s) {
+			ignores = append(ignores, s)
+		}
+	}
+
+	// signal.Ignore would be inherited by the sub process, so the ignored
+	// signals are received and dropped
+	ignored := make(chan os.Signal, 1)
+	if len(ignores) > 0 {
+		signal.Notify(ignored, ignores...)
+		defer signal.Stop(ignored)
+	}
+
+	sig := make(chan os.Signal, 1)
+	if len(signals) > 0 {
+		signal.Notify(sig, signals...)
+		defer signal.Stop(sig)
+	}
+
+	exited := make(chan error, 1)
 	go func() {
-		<-sig
+		exited <- cmder.Wait()
 	}()
+
+	for {
+		select {
+		case err = <-exited:
+			return
+		case <-ignored:
+		case s := <-sig:
+			if killSignal != nil && s == killSignal {
+				killProcess(cmder.Process.Pid)
+			} else {
+				// preStop runs once before the first stop signal is forwarded
+				if preStop != nil {
+					preStop()
+					preStop = nil
+				}
+				cmder.Process.Signal(s)
+			}
+		}
+	}
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
 }
 
-var onceProcessSignals sync.Once
+func parseSignal(name string) (sig os.Signal, err error) {
+	name = strings.TrimPrefix(strings.ToUpper(name), "SIG")
+
+	var exist bool
+	if sig, exist = signalNames[name]; !exist {
+		err = fmt.Errorf("unknown signal: %s", name)
+		return
+	}
+
+	return
+}
+
+// interruptContext returns a context which is canceled by interrupt or
+// SIGTERM
+func interruptContext() (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(context.Background())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, interruptSignals...)
+
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigChan)
+	}()
 
-func startSigHandlers() {
-	onceProcessSignals.Do(processSignals)
+	return
 }
@@ -3,7 +3,21 @@
 package main
 
 import (
+	"fmt"
 	"os"
 )
 
 var signalsToIgnore = []os.Signal{os.Interrupt}
+
+var defaultStopSignals = []os.Signal{}
+
+var interruptSignals = []os.Signal{os.Interrupt}
+
+var signalNames = map[string]os.Signal{
+	"INT": os.Interrupt,
+}
+
+// reloadProcess is not supported without SIGHUP
+func reloadProcess(pid int) error {
+	return fmt.Errorf("reload process %d is not supported on this platform", pid)
+}
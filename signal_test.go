@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"INT", "int", "SIGINT", "sigint"} {
+		sig, err := parseSignal(name)
+		if err != nil {
+			t.Errorf("parse signal %s failed: %s", name, err)
+			continue
+		}
+		if sig != os.Interrupt {
+			t.Errorf("signal %s is parsed as %s, want %s", name, sig, os.Interrupt)
+		}
+	}
+
+	for _, name := range []string{"", "SIG", "NOPE", "INTX"} {
+		if _, err := parseSignal(name); err == nil {
+			t.Errorf("parse signal %q should fail", name)
+		}
+	}
+}
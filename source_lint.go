@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	LintRuleSourceDuplicateURNs = "source-duplicate-urns"
+	LintRuleSourceEmptyFields   = "source-empty-fields"
+	LintRuleSourcePackagePaths  = "source-package-paths"
+)
+
+// packagePathRegexp matches the go import path, e.g. github.com/gogap/spirit
+var packagePathRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9._~+-]+)*$`)
+
+// addURNPackage maps the urn of urnPkg to its package, it returns the other
+// package already mapped to the urn and keeps it
+func addURNPackage(urnPkgs map[string]string, urnPkg URNPackage) (clash string) {
+	if oldVal, exist := urnPkgs[urnPkg.URN]; exist && oldVal != urnPkg.Pkg {
+		return oldVal
+	}
+	urnPkgs[urnPkg.URN] = urnPkg.Pkg
+	return ""
+}
+
+// ValidateSourceConfig checks a single source file without any config: the
+// duplicate urns, the empty urns and packages, the malformed package paths
+func ValidateSourceConfig(filename string) (findings []LintFinding, err error) {
+	var sourceConf SourceConfig
+	if sourceConf, err = loadSourceConfig(filename); err != nil {
+		return
+	}
+
+	report := func(rule, severity, format string, v ...interface{}) {
+		findings = append(findings, LintFinding{Rule: rule, Severity: severity, Message: filename + ": " + fmt.Sprintf(format, v...)})
+	}
+
+	urnPkgs := map[string]string{}
+	seen := map[URNPackage]bool{}
+
+	for i, urnPkg := range sourceConf.Packages {
+		if urnPkg.URN == "" || urnPkg.Pkg == "" {
+			report(LintRuleSourceEmptyFields, LintSeverityError, "package #%d has empty urn or pkg, urn: %s, pkg: %s", i, urnPkg.URN, urnPkg.Pkg)
+			continue
+		}
+
+		if strings.Contains(urnPkg.Pkg, "://") || !packagePathRegexp.MatchString(urnPkg.Pkg) {
+			report(LintRuleSourcePackagePaths, LintSeverityError, "pkg of urn %s is not a go import path: %s", urnPkg.URN, urnPkg.Pkg)
+		}
+
+		if clash := addURNPackage(urnPkgs, urnPkg); clash != "" {
+			report(LintRuleSourceDuplicateURNs, LintSeverityError, "urn %s is mapped to both %s and %s", urnPkg.URN, clash, urnPkg.Pkg)
+		} else if seen[urnPkg] {
+			report(LintRuleSourceDuplicateURNs, LintSeverityWarning, "urn %s is listed more than once", urnPkg.URN)
+		}
+		seen[urnPkg] = true
+	}
+
+	return
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// spiritImportPath is the import path of spirit, the actors are registered
+// by its Register* functions, e.g. spirit.RegisterComponent(urn, newFunc)
+const spiritImportPath = "github.com/gogap/spirit"
+
+// ScanSource scans the packages under gosrc/prefix for the actor
+// registrations of spirit and returns the source config of the found urns,
+// problems are the registrations whose urn is not a string literal or
+// constant, and the urns registered by more than one package, which are left
+// out of the source config
+func ScanSource(gosrc string, prefixes ...string) (sourceConf SourceConfig, problems []string, err error) {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	urnPkgs := map[string][]string{}
+
+	for _, prefix := range prefixes {
+		root := path.Join(gosrc, prefix)
+
+		err = filepath.Walk(root, func(dir string, info os.FileInfo, e error) error {
+			if e != nil {
+				return e
+			}
+
+			if !info.IsDir() {
+				return nil
+			}
+
+			if name := info.Name(); dir != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+
+			pkg := strings.TrimPrefix(strings.TrimPrefix(dir, path.Clean(gosrc)), "/")
+
+			urns, dirProblems := scanPackageRegistrations(dir, pkg)
+			problems = append(problems, dirProblems...)
+
+			for _, urn := range urns {
+				urnPkgs[urn] = append(urnPkgs[urn], pkg)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return
+		}
+	}
+
+	var urns []string
+	for urn := range urnPkgs {
+		urns = append(urns, urn)
+	}
+	sort.Strings(urns)
+
+	for _, urn := range urns {
+		pkgs := urnPkgs[urn]
+		if len(pkgs) > 1 {
+			problems = append(problems, fmt.Sprintf("urn %s is registered by more than one package: %s", urn, strings.Join(pkgs, ", ")))
+			continue
+		}
+		sourceConf.Packages = append(sourceConf.Packages, URNPackage{URN: urn, Pkg: pkgs[0]})
+	}
+
+	sourceConf.UpdateTime = time.Now().Format("2006-01-02 15:04:05")
+
+	return
+}
+
+// scanPackageRegistrations returns the urns registered by the non-test go
+// files of dir, the urn argument is resolved if it is a string literal or a
+// string constant of the package
+func scanPackageRegistrations(dir string, pkg string) (urns []string, problems []string) {
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("parse package %s failed, %s", pkg, err))
+		return
+	}
+
+	for _, astPkg := range pkgs {
+		consts := map[string]string{}
+		for _, file := range astPkg.Files {
+			collectStringConsts(file, consts)
+		}
+
+		for _, file := range astPkg.Files {
+			spiritName := importName(file, spiritImportPath)
+			if spiritName == "" {
+				continue
+			}
+
+			ast.Inspect(file, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok || len(call.Args) == 0 {
+					return true
+				}
+
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || !strings.HasPrefix(sel.Sel.Name, "Register") {
+					return true
+				}
+
+				if x, ok := sel.X.(*ast.Ident); !ok || x.Name != spiritName {
+					return true
+				}
+
+				if urn, ok := stringValue(call.Args[0], consts); ok {
+					urns = append(urns, urn)
+				} else {
+					problems = append(problems, fmt.Sprintf("urn of spirit.%s at %s is not a string literal or constant", sel.Sel.Name, fset.Position(call.Pos())))
+				}
+
+				return true
+			})
+		}
+	}
+
+	return
+}
+
+// importName returns the name which file refers the import path by, it is
+// empty if file does not import it
+func importName(file *ast.File, importPath string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != importPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path.Base(importPath)
+	}
+	return ""
+}
+
+// collectStringConsts collects the constants of file which are string
+// literals
+func collectStringConsts(file *ast.File, consts map[string]string) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, name := range valueSpec.Names {
+				if i < len(valueSpec.Values) {
+					if v, ok := stringValue(valueSpec.Values[i], nil); ok {
+						consts[name.Name] = v
+					}
+				}
+			}
+		}
+	}
+}
+
+// stringValue returns the value of string literal, or of the constant in
+// consts
+func stringValue(expr ast.Expr, consts map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			if v, err := strconv.Unquote(e.Value); err == nil {
+				return v, true
+			}
+		}
+	case *ast.Ident:
+		v, ok := consts[e.Name]
+		return v, ok
+	}
+	return "", false
+}
@@ -2,21 +2,32 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gogap/spirit"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
 
+// generatedHeader marks the generated source as generated code, it matches
+// the convention of `^// Code generated .* DO NOT EDIT\.$`
+const generatedHeader = "// Code generated by spirit-tool; DO NOT EDIT.\n\n"
+
 var (
 	ErrNoURNPackageSourceFound = errors.New("no urn packages source found")
 	ErrConfigFileNameIsEmpty   = errors.New("config file name is empty")
+	ErrDetachWithOnStop        = errors.New("on stop is not supported with detach, the detached project is not waited")
 )
 
 type SpiritHelper struct {
@@ -24,11 +35,61 @@ type SpiritHelper struct {
 	configFile     string
 	configFileName string
 	originalConfig []byte
+	configContent  []byte
+	directive      configDirective
 
 	RefURNs     []string
 	RefPackages []Package
+	urnPackages map[string]string
+	sourceCache map[string]SourceConfig
+
+	// Timings are the durations of the phases of last CreateProject
+	Timings    []PhaseTiming
+	phase      string
+	phaseStart time.Time
+
+	// Logger receives the logs of SpiritHelper, default is spirit.Logger()
+	Logger Logger
+
+	templateRoot string
+	format       string
+	httpTimeout  time.Duration
+	httpAuth     string
+	strict       bool
+
+	// memSources are the sources added by AddSource
+	memSources []SourceConfig
+
+	// configErr is the parse error of the loaded config with template
+	// placeholders, it is cleared after the config is rendered
+	configErr error
+
+	// logFile receives the logs of helper when createOpts.LogFile is set
+	logFile  *os.File
+	logMutex *sync.Mutex
+
+	// unresolvedURNs are the urns skipped by AllowUnresolvedURNs
+	unresolvedURNs []string
+
+	createTime time.Time
+	lock       LockFile
+
+	fetchMutex *sync.Mutex
+	fetched    *fetchRecord
+	ctx        context.Context
+
+	// get fetches a package, default is pkg.Get, it is replaced by tests
+	get func(pkg *Package, update bool) error
+
+	// warnings records the warnings of CreateProject if it is not nil
+	warnings *[]string
+
+	// Stdin is read for the missing template args in interactive mode,
+	// default is os.Stdin
+	Stdin io.Reader
 }
 
+// LoadSpiritConfig loads the config from file, or http(s) url
 func (p *SpiritHelper) LoadSpiritConfig(filename string) (err error) {
 
 	if filename == "" {
@@ -36,49 +97,125 @@ func (p *SpiritHelper) LoadSpiritConfig(filename string) (err error) {
 		return
 	}
 
-	if fi, e := os.Stat(filename); e != nil {
-		err = e
-		return
-	} else {
+	if isRemoteConfig(filename) {
+		if p.originalConfig, p.configFileName, err = p.fetchRemoteConfig(filename); err != nil {
+			return
+		}
 		p.configFile = filename
-		p.configFileName = fi.Name()
+	} else {
+		if fi, e := os.Stat(filename); e != nil {
+			err = e
+			return
+		} else {
+			p.configFile = filename
+			p.configFileName = fi.Name()
+		}
+
+		if p.originalConfig, err = ioutil.ReadFile(filename); err != nil {
+			return
+		}
 	}
 
-	if p.originalConfig, err = ioutil.ReadFile(filename); err != nil {
+	if err = p.parseConfig(); err != nil {
+		// the config with template placeholders is parsed after rendered
+		// by TemplateConfig
+		if bytes.Contains(p.originalConfig, []byte("//<-")) {
+			p.configErr = err
+			err = nil
+		}
+		return
+	}
+
+	return
+}
+
+// parseConfig parses the spirit config and the directive of loaded config
+func (p *SpiritHelper) parseConfig() (err error) {
+	if p.strict {
+		if err = p.unmarshalStrictConfig(p.originalConfig, &p.conf); err != nil {
+			return
+		}
+	} else if err = p.unmarshalConfig(p.originalConfig, &p.conf); err != nil {
 		return
 	}
 
-	if err = json.Unmarshal(p.originalConfig, &p.conf); err != nil {
+	if err = p.unmarshalConfig(p.originalConfig, &p.directive); err != nil {
 		return
 	}
 
 	return
 }
 
+// Reset clears the loaded config and the data derived from it, so the helper
+// could be reused for another config, the options of helper are kept
+func (p *SpiritHelper) Reset() {
+	*p = SpiritHelper{
+		Logger:       p.Logger,
+		Stdin:        p.Stdin,
+		templateRoot: p.templateRoot,
+		format:       p.format,
+		httpTimeout:  p.httpTimeout,
+		httpAuth:     p.httpAuth,
+		strict:       p.strict,
+		memSources:   p.memSources,
+	}
+}
+
 func (p *SpiritHelper) CreateProject(createOpts CreateOptions, tmplArgs map[string]interface{}) (err error) {
-	if err = createOpts.Validate(); err != nil {
+	var closeLog func(error)
+	if closeLog, err = p.openLogFile(createOpts); err != nil {
 		return
 	}
+	defer func() { closeLog(err) }()
 
-	goSrc := path.Join(createOpts.GoPath, "src")
+	if createOpts.WarningsAsErrors {
+		warnings := []string{}
+		p.warnings = &warnings
 
-	if err = p.parse(goSrc, createOpts.Sources); err != nil {
+		defer func() {
+			p.warnings = nil
+			if err == nil && len(warnings) > 0 {
+				err = fmt.Errorf("%d warnings treated as errors: %s", len(warnings), strings.Join(warnings, "; "))
+			}
+		}()
+	}
+
+	p.Timings = nil
+	defer func() {
+		p.endPhase(err != nil)
+	}()
+
+	p.startPhase(PhaseValidate)
+
+	var goSrc string
+	if goSrc, err = p.loadProject(&createOpts, tmplArgs); err != nil {
 		return
 	}
 
-	// download packages
-	if createOpts.GetPackages {
-		if err = p.GetPackages(goSrc, createOpts.PackagesRevision, createOpts.UpdatePackages); err != nil {
+	// download packages, they are fetched into project after generated in
+	// vendor mode
+	if createOpts.GetPackages && !createOpts.Vendor {
+		p.startPhase(PhaseGetPackages)
+		if err = p.GetPackages(createOpts); err != nil {
 			return
 		}
+	} else if createOpts.Vendor {
+		// the revisions are applied before rendering as GetPackages does, so
+		// the template and vendorPackages see the same packages
+		var pkgRevision map[string]string
+		if pkgRevision, err = createOpts.packagesRevision(); err != nil {
+			return
+		}
+		p.applyPackagesRevision(goSrc, pkgRevision)
 	}
 
-	// make project dir
-	projectPath := path.Join(goSrc, createOpts.ProjectPath)
-	if path.IsAbs(createOpts.ProjectPath) {
-		projectPath = createOpts.ProjectPath
+	if createOpts.VerifyURNRegistrations && !createOpts.DryRun {
+		p.verifyURNRegistrations(goSrc)
 	}
 
+	// make project dir
+	projectPath := createOpts.projectDir()
+
 	if !createOpts.IsTempPath {
 		if fi, e := os.Stat(projectPath); e != nil {
 			if !strings.Contains(e.Error(), "no such file or directory") &&
@@ -90,7 +227,9 @@ func (p *SpiritHelper) CreateProject(createOpts CreateOptions, tmplArgs map[stri
 			err = fmt.Errorf("your project path %s already exist, but it is not a directory", projectPath)
 			return
 		} else if createOpts.ForceWrite {
-			spirit.Logger().Warnf("project path %s already exist, it will be overwrite", projectPath)
+			p.logger().Warnf("project path %s already exist, it will be overwrite", projectPath)
+		} else if createOpts.outputFileName() != "main.go" {
+			p.logger().Infof("project path %s already exist, generate into %s", projectPath, createOpts.outputFileName())
 		} else {
 			err = fmt.Errorf("your project path %s already exist", projectPath)
 			return
@@ -106,200 +245,952 @@ func (p *SpiritHelper) CreateProject(createOpts CreateOptions, tmplArgs map[stri
 		err = nil
 	}
 
+	writeLock := createOpts.WriteLockFile || createOpts.PrunePackages
+
+	lockPath := path.Join(projectPath, LockFileName)
+
+	var previousLock LockFile
+	lockErr := os.ErrNotExist
+	if writeLock {
+		previousLock, lockErr = LoadLockFile(lockPath)
+	}
+
 	// render code template
-	tmplPathFmt := "github.com/gogap/spirit-tool/template/%s/main.go"
-	tmplArgsPathFmt := "github.com/gogap/spirit-tool/template/%s/args.json"
+	p.startPhase(PhaseRender)
 
-	tmplPath := path.Join(goSrc, fmt.Sprintf(tmplPathFmt, createOpts.TemplateName))
-	spirit.Logger().Infof("using template of %s: %s", createOpts.TemplateName, tmplPath)
+	p.logger().Infof("using template of %s: %s", createOpts.TemplateName, createOpts.templateDir())
 
-	tmplArgsPath := path.Join(goSrc, fmt.Sprintf(tmplArgsPathFmt, createOpts.TemplateName))
-	spirit.Logger().Infof("using template args of %s: %s", createOpts.TemplateName, tmplArgsPath)
+	if err = p.prepareRender(createOpts, tmplArgs); err != nil {
+		return
+	}
 
-	var tmpl *template.Template
-	if tmpl, err = template.New("main.go").Option("missingkey=error").Delims("//<-", "->//").ParseFiles(tmplPath); err != nil {
+	var generated []generatedFile
+	if generated, err = p.renderProject(createOpts, tmplArgs); err != nil {
 		return
 	}
 
-	internalArgs := map[string]interface{}{}
-	if argData, e := ioutil.ReadFile(tmplArgsPath); e == nil {
-		if err = json.Unmarshal(argData, &internalArgs); err != nil {
+	p.startPhase(PhaseWrite)
+
+	var srcPaths []string
+	for _, file := range generated {
+		var written bool
+		if written, err = p.writeProjectFile(createOpts, projectPath, file.Name, file.Data); err != nil {
 			return
 		}
+
+		if written && path.Ext(file.Name) == ".go" {
+			srcPaths = append(srcPaths, path.Join(projectPath, file.Name))
+		}
+	}
+
+	if _, err = p.writeProjectFile(createOpts, projectPath, p.projectConfigFileName(createOpts), p.configContent); err != nil {
+		return
 	}
 
-	if tmplArgs != nil {
-		for k, v := range tmplArgs {
-			internalArgs[k] = v
+	if createOpts.WriteEffectiveOptions {
+		if err = p.writeEffectiveOptions(createOpts, projectPath); err != nil {
+			return
 		}
 	}
 
-	buffer := &bytes.Buffer{}
-	if err = tmpl.Execute(buffer, map[string]interface{}{
-		"create_options":  createOpts,
-		"packages":        p.RefPackages,
-		"config":          p.configFile,
-		"config_filename": p.configFileName,
-		"create_time":     time.Now(),
-		"args":            internalArgs}); err != nil {
+	var optionalFiles []generatedFile
+	if optionalFiles, err = p.optionalFiles(createOpts); err != nil {
 		return
 	}
 
-	srcPath := path.Join(projectPath, "main.go")
-	if err = ioutil.WriteFile(srcPath, buffer.Bytes(), os.FileMode(0644)); err != nil {
+	for _, file := range optionalFiles {
+		var written bool
+		if written, err = p.writeProjectFile(createOpts, projectPath, file.Name, file.Data); err != nil {
+			return
+		}
+
+		if written && path.Ext(file.Name) == ".go" {
+			srcPaths = append(srcPaths, path.Join(projectPath, file.Name))
+		}
+	}
+
+	// format code for sort import packages order
+	if len(srcPaths) > 0 && !createOpts.SkipFormat {
+		p.startPhase(PhaseFormat)
+		if _, err = execCommand("go fmt " + strings.Join(srcPaths, " ")); err != nil {
+			return
+		}
+	}
+
+	if createOpts.Vendor {
+		p.startPhase(PhaseVendor)
+		if err = p.vendorPackages(createOpts); err != nil {
+			return
+		}
+	}
+
+	if createOpts.VetGenerated {
+		p.startPhase(PhaseVet)
+		if out, e := execCommandWithEnv("go vet .", projectPath, createOpts.buildEnvs()); e != nil {
+			err = fmt.Errorf("go vet of generated project %s failed, %s:\n%s", projectPath, e, out)
+			return
+		}
+	}
+
+	if writeLock {
+		p.startPhase(PhaseLock)
+
+		p.lock = newLockFile(p.RefPackages)
+		if err = p.lock.Save(lockPath); err != nil {
+			return
+		}
+
+		if lockErr == nil {
+			if err = p.prunePackages(goSrc, projectPath, previousLock, createOpts.PrunePackages); err != nil {
+				return
+			}
+		}
+	}
+
+	p.logger().Infof("project created at %s\n", projectPath)
+
+	return
+}
+
+// optionalFiles returns the files generated by the options besides the
+// template and the config: the systemd unit, the .gitignore, the embedded
+// config and the config validation test
+func (p *SpiritHelper) optionalFiles(createOpts CreateOptions) (files []generatedFile, err error) {
+	if createOpts.SystemdUnit {
+		var unit generatedFile
+		if unit, err = p.systemdUnitFile(createOpts); err != nil {
+			return
+		}
+		files = append(files, unit)
+	}
+
+	if createOpts.GenerateGitignore {
+		files = append(files, generatedFile{Name: ".gitignore", Data: p.gitignoreData(createOpts)})
+	}
+
+	if createOpts.EmbedConfig {
+		files = append(files, generatedFile{Name: embeddedConfigFileName, Data: p.embeddedConfigData()})
+	}
+
+	if createOpts.GenerateTests {
+		if format := createOpts.OutputConfigFormat; format == ConfigFormatYAML || format == ConfigFormatTOML {
+			p.logger().Warnf("config validation test is not generated, it reads json config only")
+		} else {
+			var testData []byte
+			if testData, err = p.configTestData(createOpts); err != nil {
+				return
+			}
+			files = append(files, generatedFile{Name: configTestFileName, Data: testData})
+		}
+	}
+
+	return
+}
+
+// renderTemplateFiles renders the template files which should be emitted,
+// the go files have the generated header unless it is skipped
+func (p *SpiritHelper) renderTemplateFiles(createOpts CreateOptions, args map[string]interface{}) (generated []generatedFile, err error) {
+	var files []templateFile
+	if files, err = p.templateFiles(createOpts, args); err != nil {
 		return
 	}
 
-	confPath := path.Join(projectPath, p.configFileName)
-	if err = ioutil.WriteFile(confPath, p.originalConfig, os.FileMode(0644)); err != nil {
+	var emitted []templateFile
+	var tmpls []*template.Template
+
+	for _, file := range files {
+		var emit bool
+		if emit, err = file.shouldEmit(p.templateData(createOpts, args)); err != nil {
+			return
+		} else if !emit {
+			p.logger().Infof("template file %s is not emitted", file.Path)
+			continue
+		}
+
+		var tmpl *template.Template
+		if tmpl, err = file.parse(); err != nil {
+			return
+		}
+
+		if err = p.completeArgs(createOpts, tmpl, args); err != nil {
+			return
+		}
+
+		emitted = append(emitted, file)
+		tmpls = append(tmpls, tmpl)
+	}
+
+	data := p.templateData(createOpts, args)
+
+	for i, file := range emitted {
+		buffer := &bytes.Buffer{}
+		if err = tmpls[i].Execute(buffer, data); err != nil {
+			err = withTemplateSource(err, file.Source)
+			return
+		}
+
+		src := buffer.Bytes()
+		if !createOpts.SkipSyntaxCheck && path.Ext(file.Name) == ".go" {
+			if err = checkGoSyntax(file, src); err != nil {
+				return
+			}
+		}
+
+		if len(p.unresolvedURNs) > 0 && file.Name == createOpts.outputFileName() {
+			src = insertUnresolvedURNsComment(src, p.unresolvedURNs)
+		}
+
+		if !createOpts.SkipGeneratedHeader && path.Ext(file.Name) == ".go" {
+			src = append([]byte(generatedHeader), src...)
+		}
+
+		generated = append(generated, generatedFile{Name: file.Name, Data: src})
+	}
+
+	return
+}
+
+// writeProjectFile writes the file name into project, the existing file
+// matches createOpts.Preserve is kept as is
+func (p *SpiritHelper) writeProjectFile(createOpts CreateOptions, projectPath string, name string, data []byte) (written bool, err error) {
+	filename := path.Join(projectPath, name)
+
+	if createOpts.isPreserved(name) {
+		if _, e := os.Stat(filename); e == nil {
+			p.logger().Infof("file %s is preserved", filename)
+			return
+		}
+	}
+
+	if err = os.MkdirAll(path.Dir(filename), os.FileMode(0755)); err != nil {
 		return
 	}
 
-	// format code for sort import packages order
-	if _, err = execCommand("go fmt " + srcPath); err != nil {
+	if err = ioutil.WriteFile(filename, data, os.FileMode(0644)); err != nil {
 		return
 	}
 
-	spirit.Logger().Infof("project created at %s\n", projectPath)
+	written = true
 
 	return
 }
 
-func (p *SpiritHelper) GetPackages(gosrc string, pkgRevision map[string]string, update bool) (err error) {
+// projectConfigFileName returns the file name of the config copied into
+// project
+func (p *SpiritHelper) projectConfigFileName(createOpts CreateOptions) string {
+	if createOpts.ConfigFileName != "" {
+		return createOpts.ConfigFileName
+	}
+	return outputConfigFileName(p.configFileName, p.projectConfigFormat(createOpts))
+}
 
-	existPkg := make(map[string]bool)
+// GetPackages fetches the referenced packages into GOPATH by `go get`, if the
+// GOPROXY list of createOpts has any proxy they are fetched through it in
+// module mode instead of vcs
+func (p *SpiritHelper) GetPackages(createOpts CreateOptions) (err error) {
+	var closeLog func(error)
+	if closeLog, err = p.openLogFile(createOpts); err != nil {
+		return
+	}
+	defer func() { closeLog(err) }()
+
+	gosrc := path.Join(createOpts.GoPath, "src")
+	update := createOpts.UpdatePackages
 
-	for _, pkg := range p.RefPackages {
-		if pkgRevision != nil {
-			if revision, exist := pkgRevision[pkg.URI]; exist {
+	var pkgRevision map[string]string
+	if pkgRevision, err = createOpts.packagesRevision(); err != nil {
+		return
+	}
+
+	if p.fetchMutex != nil {
+		p.fetchMutex.Lock()
+		defer p.fetchMutex.Unlock()
+	}
+
+	if createOpts.DryRun {
+		var plans []PackagePlan
+		if plans, err = p.PlanPackages(createOpts); err != nil {
+			return
+		}
+
+		for _, plan := range plans {
+			p.logger().Infof("dry run: %s", plan)
+		}
+
+		return
+	}
+
+	p.applyPackagesRevision(gosrc, pkgRevision)
+
+	proxy := createOpts.packagesProxy()
+	if proxy != "" {
+		p.logger().Infof("fetch packages through GOPROXY: %s", proxy)
+	}
+
+	// pkg points into RefPackages, so the fetched revision is kept for the
+	// template and lock file, not only applied to a copy
+	for i := range p.RefPackages {
+		pkg := &p.RefPackages[i]
+
+		if createOpts.UseCheckedOut {
+			if revision, ok := checkedOutRevision(pkg); ok {
+				p.logger().Infof("package %s is checked out at %s, it is not fetched", pkg.URI, revision)
 				pkg.Revision = revision
+				continue
 			}
-			existPkg[pkg.URI] = true
 		}
-		if err = pkg.Get(update); err != nil {
+
+		pkg.proxy = proxy
+		pkg.timeout = createOpts.FetchTimeout
+		pkg.since = createOpts.UpdateSince
+		pkg.envs = createOpts.packageEnvs(pkg.URI)
+		pkg.ctx = p.ctx
+		pkg.logger = p.logger()
+		if err = p.getPackage(pkg, update); err != nil {
 			return
 		}
 	}
 
-	if pkgRevision != nil {
-		for uri, revision := range pkgRevision {
-			if _, exist := existPkg[uri]; !exist {
+	return
+}
 
-				pkg := Package{gosrc, uri, revision}
-				p.RefPackages = append(p.RefPackages, pkg)
+// applyPackagesRevision overrides the revisions of RefPackages by
+// pkgRevision, the packages only in pkgRevision are appended, then the
+// packages are sorted by uri
+func (p *SpiritHelper) applyPackagesRevision(gosrc string, pkgRevision map[string]string) {
+	if len(pkgRevision) == 0 {
+		return
+	}
 
-				if err = pkg.Get(update); err != nil {
-					return
+	existPkg := make(map[string]bool)
+
+	for i := range p.RefPackages {
+		pkg := &p.RefPackages[i]
+		if revision, exist := pkgRevision[pkg.URI]; exist {
+			pkg.Revision = revision
+		}
+		existPkg[pkg.URI] = true
+	}
+
+	for uri, revision := range pkgRevision {
+		if !existPkg[uri] {
+			p.RefPackages = append(p.RefPackages, Package{gosrc: gosrc, URI: uri, Revision: revision})
+		}
+	}
+
+	sort.Sort(packagesByURI(p.RefPackages))
+}
+
+// getPackage fetches pkg, it is skipped if it is already fetched with the
+// same revision by another project of GenerateAll
+func (p *SpiritHelper) getPackage(pkg *Package, update bool) (err error) {
+	if p.fetched != nil {
+		if revision, exist := p.fetched.packages[pkg.URI]; exist && revision == pkg.Revision {
+			p.fetched.skipped++
+			return
+		}
+	}
+
+	if p.get != nil {
+		err = p.get(pkg, update)
+	} else {
+		err = pkg.Get(update)
+	}
+
+	if err != nil {
+		return
+	}
+
+	if p.fetched != nil {
+		p.fetched.packages[pkg.URI] = pkg.Revision
+	}
+
+	return
+}
+
+// applyPackagesHook replaces RefPackages by the result of
+// createOpts.PackagesHook, the packages added by hook are under gosrc
+func (p *SpiritHelper) applyPackagesHook(gosrc string, createOpts CreateOptions) (err error) {
+	if createOpts.PackagesHook == nil {
+		return
+	}
+
+	var packages []Package
+	if packages, err = createOpts.PackagesHook(p.RefPackages); err != nil {
+		err = fmt.Errorf("packages hook failed, %s", err)
+		return
+	}
+
+	for i := range packages {
+		if packages[i].gosrc == "" {
+			packages[i].gosrc = gosrc
+		}
+	}
+
+	p.RefPackages = packages
+
+	return
+}
+
+func (p *SpiritHelper) appendExtraPackages(gosrc string, extraPkgs map[string]string) {
+	for uri, revision := range extraPkgs {
+		exist := false
+		for i := range p.RefPackages {
+			if p.RefPackages[i].URI == uri {
+				if revision != "" {
+					p.RefPackages[i].Revision = revision
 				}
+				exist = true
+				break
 			}
 		}
+
+		if !exist {
+			p.RefPackages = append(p.RefPackages, Package{gosrc: gosrc, URI: uri, Revision: revision})
+		}
+	}
+}
+
+// loadProject completes createOpts with the defaults, validates it and the
+// loaded config, then resolves the packages of config, it is shared by
+// CreateProject, PrintTemplateData and ContentHash, goSrc is the src dir of
+// GOPATH, the parse phase is timed only if a phase is being timed
+func (p *SpiritHelper) loadProject(createOpts *CreateOptions, tmplArgs map[string]interface{}) (goSrc string, err error) {
+	createOpts.templateRoot = p.templateRoot
+
+	if createOpts.TemplateName == "" {
+		createOpts.TemplateName = p.directive.SpiritTool.Template
+	}
+
+	if err = createOpts.Validate(); err != nil {
+		return
+	}
+
+	if createOpts.TemplateConfig {
+		if err = p.renderConfig(*createOpts, tmplArgs); err != nil {
+			return
+		}
+	}
+
+	if err = p.Validate(); err != nil {
+		return
+	}
+
+	if createOpts.StrictURNContexts {
+		if err = p.ValidateURNContexts(createOpts.URNContextWhitelist...); err != nil {
+			return
+		}
+	}
+
+	if err = createOpts.checkGoSrc(); err != nil {
+		return
+	}
+
+	goSrc = path.Join(createOpts.GoPath, "src")
+
+	if p.phase != "" {
+		p.startPhase(PhaseParse)
+	}
+
+	if err = p.parse(goSrc, *createOpts); err != nil {
+		return
+	}
+
+	p.appendExtraPackages(goSrc, createOpts.ExtraPackages)
+
+	if err = p.applyPackagesHook(goSrc, *createOpts); err != nil {
+		return
+	}
+
+	sort.Sort(packagesByURI(p.RefPackages))
+
+	return
+}
+
+// prepareRender sets the create time and the config content which the
+// templates are rendered with, and checks the templates could read the config
+func (p *SpiritHelper) prepareRender(createOpts CreateOptions, tmplArgs map[string]interface{}) (err error) {
+	if p.createTime, err = createOpts.createTime(); err != nil {
+		return
+	}
+
+	if p.configContent, err = p.projectConfig(createOpts); err != nil {
+		return
+	}
+
+	if err = p.checkConfigFormat(createOpts, tmplArgs); err != nil {
+		return
 	}
 
 	return
 }
 
 func (p *SpiritHelper) BuildProject(createOpts CreateOptions, name string, tmplArgs map[string]interface{}) (err error) {
+	var closeLog func(error)
+	if closeLog, err = p.openLogFile(createOpts); err != nil {
+		return
+	}
+	defer func() { closeLog(err) }()
 
 	if err = p.CreateProject(createOpts, tmplArgs); err != nil {
 		return
 	}
 
-	cmd := "go build -o "
+	projectPath := createOpts.projectDir()
+
+	name = createOpts.binaryPath(name)
+	if err = os.MkdirAll(path.Dir(name), os.FileMode(0755)); err != nil {
+		return
+	}
+
+	profile := createOpts.BuildProfiles[createOpts.SelectedProfile]
+
+	args := []string{"build"}
 	if verbosity > 0 {
-		cmd = "go build -v -o "
+		args = append(args, "-v")
+	}
+
+	if createOpts.Vendor {
+		args = append(args, "-mod=vendor")
 	}
 
+	args = append(args, profile.Flags...)
+
+	if len(profile.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(profile.Tags, ","))
+	}
+
+	ldflags := append([]string{}, profile.LDFlags...)
+	if createOpts.VersionVarPath != "" {
+		ldflags = append(ldflags, p.buildInfoLDFlags(createOpts)...)
+	}
+
+	if len(ldflags) > 0 {
+		args = append(args, "-ldflags", strings.Join(ldflags, " "))
+	}
+
+	// the generated file is a part of the package if it is not main.go
+	target := path.Join(projectPath, "main.go")
+	if ep, ok := createOpts.selectedEntrypoint(); ok {
+		target = "./" + path.Dir(ep.Output)
+	} else if createOpts.outputFileName() != "main.go" {
+		target = "."
+	}
+
+	args = append(args, "-o", name, target)
+
+	ctx := context.Background()
+	if createOpts.BuildTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, createOpts.BuildTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
 	var out []byte
-	if out, err = execCommandWithDir(cmd+name+" "+path.Join(createOpts.ProjectPath, "main.go"), createOpts.ProjectPath); err != nil {
-		spirit.Logger().Errorln(string(out))
+	if out, err = execCommandArgsContext(ctx, projectPath, append(createOpts.buildEnvs(), profile.Env...), "go", args...); err != nil {
+		p.logger().Errorf("%s", out)
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("go build of %s timeout after %s", projectPath, time.Since(start))
+		}
+		return
+	}
+
+	if err = p.copyArtifacts(createOpts); err != nil {
+		return
+	}
+
+	if err = p.stageLayout(createOpts, name); err != nil {
+		return
+	}
+
+	return
+}
+
+// BuildOnly creates the project and builds the binary main, or the binary of
+// selected entrypoint, into it without running, it returns the path of binary
+func (p *SpiritHelper) BuildOnly(createOpts CreateOptions, tmplArgs map[string]interface{}) (binPath string, err error) {
+	binPath = createOpts.binaryPath("main")
+	if ep, ok := createOpts.selectedEntrypoint(); ok {
+		binPath = createOpts.binaryPath(ep.Name)
+	}
+
+	if err = p.BuildProject(createOpts, binPath, tmplArgs); err != nil {
 		return
 	}
 
 	return
 }
 
-func (p *SpiritHelper) RunProject(createOpts CreateOptions, detach bool, envs []string, tmplArgs map[string]interface{}) (err error) {
-	if err = p.BuildProject(createOpts, "main", tmplArgs); err != nil {
+// buildInfoLDFlags returns the -X flags which set the build info vars in
+// package createOpts.VersionVarPath
+func (p *SpiritHelper) buildInfoLDFlags(createOpts CreateOptions) (flags []string) {
+	// the lock is only recorded by create if it is written
+	lock := p.lock
+	if len(lock.Packages) == 0 {
+		lock = newLockFile(p.RefPackages)
+	}
+
+	var revisions []string
+	for _, pkg := range lock.Packages {
+		revisions = append(revisions, pkg.URI+"@"+pkg.Revision)
+	}
+
+	vars := [][2]string{
+		{"BuildTime", p.createTime.Format(time.RFC3339)},
+		{"BuildConfig", p.projectConfigFileName(createOpts)},
+		{"BuildToolVersion", version},
+		{"BuildRevisions", strings.Join(revisions, ",")},
+	}
+
+	for _, v := range vars {
+		flags = append(flags, "-X", createOpts.VersionVarPath+"."+v[0]+"="+v[1])
+	}
+
+	return
+}
+
+// RunProject builds and runs the project, if detach is false it waits for the
+// project to exit and returns the exit code, err is *ExitError if the project
+// exits with non-zero code
+func (p *SpiritHelper) RunProject(createOpts CreateOptions, detach bool, envs []string, tmplArgs map[string]interface{}) (exitCode int, err error) {
+	var closeLog func(error)
+	if closeLog, err = p.openLogFile(createOpts); err != nil {
+		return
+	}
+	defer func() { closeLog(err) }()
+
+	if detach && createOpts.OnStop != nil {
+		err = ErrDetachWithOnStop
+		return
+	}
+
+	// the temp project is removed once the project is not running, the
+	// detached project keeps it
+	cleanup := createOpts.IsTempPath
+	defer func() {
+		if cleanup {
+			if e := os.RemoveAll(createOpts.projectDir()); e != nil && err == nil {
+				err = e
+			}
+		}
+	}()
+
+	var binPath string
+	if binPath, err = p.BuildOnly(createOpts, tmplArgs); err != nil {
 		return
 	}
 
-	if cmder, e := execute(path.Join(createOpts.ProjectPath, "main"), createOpts.ProjectPath, !detach, envs); e != nil {
-		err = e
+	var cmder *exec.Cmd
+	if cmder, err = execute(binPath, createOpts.projectDir(), !detach, envs, createOpts.ProgramArgs...); err != nil {
 		return
-	} else if !detach {
-		startSigHandlers()
-		go func() {
+	}
+
+	if createOpts.OnStart != nil {
+		createOpts.OnStart(cmder.Process.Pid)
+	}
+
+	if createOpts.HealthCheck != nil {
+		if err = createOpts.HealthCheck.Wait(); err != nil {
+			killProcess(cmder.Process.Pid)
 			cmder.Wait()
-			close(subProcExited)
-			close(interrupted)
-		}()
-		<-subProcExited
-		<-interrupted
-	} else {
-		spirit.Logger().Infof("PID: %d\n", cmder.Process.Pid)
+			if createOpts.OnStop != nil {
+				createOpts.OnStop(cmder.ProcessState.ExitCode(), err)
+			}
+			return
+		}
+		p.logger().Infof("health check of %s%s passed", createOpts.HealthCheck.Address, createOpts.HealthCheck.Path)
 	}
 
-	if createOpts.IsTempPath && !detach {
-		err = os.RemoveAll(createOpts.ProjectPath)
+	if detach {
+		cleanup = false
+		p.logger().Infof("PID: %d\n", cmder.Process.Pid)
+		return
 	}
 
+	var preStop func()
+	if createOpts.PreStop != "" {
+		preStop = func() {
+			pid := cmder.Process.Pid
+			p.logger().Infof("run pre stop command of %d: %s", pid, createOpts.PreStop)
+			if out, e := execCommandArgs(createOpts.projectDir(), []string{fmt.Sprintf("SPIRIT_PID=%d", pid)}, "sh", "-c", createOpts.PreStop); e != nil {
+				p.logger().Errorf("pre stop command failed, %s: %s", e, out)
+			}
+		}
+	}
+
+	waitErr := waitSignal(cmder, createOpts.StopSignals, createOpts.KillSignal, preStop)
+
+	if createOpts.OnStop != nil {
+		stopCode := -1
+		if cmder.ProcessState != nil {
+			stopCode = cmder.ProcessState.ExitCode()
+		}
+		createOpts.OnStop(stopCode, waitErr)
+	}
+
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+		err = &ExitError{Code: exitCode, Err: exitErr}
+		return
+	}
+
+	err = waitErr
+
 	return
 }
 
-func (p *SpiritHelper) parse(gosrc string, sources []string) (err error) {
-	if sources == nil || len(sources) == 0 {
+func (p *SpiritHelper) parse(gosrc string, createOpts CreateOptions) (err error) {
+	sources := createOpts.Sources
+	if len(sources) == 0 && len(p.memSources) == 0 {
 		err = ErrNoURNPackageSourceFound
 		return
 	}
 
-	var urns []string
+	urns := p.ExtractURNs()
+
+	if len(urns) == 0 && !createOpts.AllowEmpty {
+		err = fmt.Errorf("config %s references no urn, the project would do nothing, check the config file or use --allow-empty", p.configFile)
+		return
+	}
+
+	if sources, err = p.expandSources(sources); err != nil {
+		return
+	}
 
-	if urns = parseActorsUsingURN(
-		p.conf.InputTranslators,
-		p.conf.OutputTranslators,
-		p.conf.Inboxes,
-		p.conf.Outboxes,
-		p.conf.Receivers,
-		p.conf.Senders,
-		p.conf.Routers,
-		p.conf.Components,
-		p.conf.LabelMatchers,
-		p.conf.URNRewriters,
-		p.conf.Messengers,
-	); err != nil {
+	var urnSources []urnSource
+	if urnSources, err = p.urnSources(sources, nil); err != nil {
 		return
 	}
 
+	p.unresolvedURNs = nil
+
+	var deprecated map[string]URNDeprecation
+	if p.RefPackages, p.urnPackages, deprecated, err = urnsToPackages(gosrc, urns, createOpts.PackageOverrides, createOpts.URNVersionSeparator, createOpts.Resolvers, urnSources); err != nil {
+		e, ok := err.(*URNNotResolvedError)
+		if !ok {
+			return
+		}
+
+		urnSections := p.urnSections()
+		e.Origins = map[string][]string{}
+		for _, urn := range e.URNs {
+			e.Origins[urn] = urnSections[urn]
+		}
+
+		if !createOpts.AllowUnresolvedURNs {
+			return
+		}
+
+		p.logger().Warnf("%s, they are skipped", e)
+
+		for _, urn := range e.URNs {
+			delete(p.urnPackages, urn)
+		}
+
+		p.unresolvedURNs = e.URNs
+		err = nil
+	}
+
+	// the given deprecations win over the ones of sources
+	for urn, deprecation := range createOpts.DeprecatedURNs {
+		deprecated[urn] = deprecation
+	}
+
+	p.warnDeprecatedURNs(urns, deprecated)
+
+	return
+}
+
+// ExtractURNs returns the urns referenced by the loaded config, it needs no
+// sources and does not resolve the packages
+func (p *SpiritHelper) ExtractURNs() (urns []string) {
+	for _, section := range p.actorSections() {
+		urns = append(urns, parseActorUsingURN(section.Actors...)...)
+	}
+
+	p.RefURNs = urns
+
+	return
+}
+
+type actorSection struct {
+	Name   string
+	Actors []spirit.ActorConfig
+}
+
+// actorSections returns the actors of the loaded config grouped by the
+// config section they are declared in, the readers and writers of pools are
+// grouped into their own sections
+func (p *SpiritHelper) actorSections() (sections []actorSection) {
+	for _, ref := range p.actorSectionRefs() {
+		sections = append(sections, actorSection{ref.Name, *ref.Actors})
+	}
+
+	readerPools := actorSection{Name: "reader_pools"}
+	readers := actorSection{Name: "reader_pools.reader"}
 	for _, readerPool := range p.conf.ReaderPools {
-		urns = append(urns, parseActorUsingURN(readerPool.ActorConfig)...)
+		readerPools.Actors = append(readerPools.Actors, readerPool.ActorConfig)
 		if readerPool.Reader != nil {
-			urns = append(urns, parseActorUsingURN(*readerPool.Reader)...)
+			readers.Actors = append(readers.Actors, *readerPool.Reader)
 		}
 	}
 
+	writerPools := actorSection{Name: "writer_pools"}
+	writers := actorSection{Name: "writer_pools.writer"}
 	for _, writerPool := range p.conf.WriterPools {
-		urns = append(urns, parseActorUsingURN(writerPool.ActorConfig)...)
+		writerPools.Actors = append(writerPools.Actors, writerPool.ActorConfig)
 		if writerPool.Writer != nil {
-			urns = append(urns, parseActorUsingURN(*writerPool.Writer)...)
+			writers.Actors = append(writers.Actors, *writerPool.Writer)
 		}
 	}
 
-	p.RefURNs = urns
+	sections = append(sections, readerPools, readers, writerPools, writers)
+
+	return
+}
 
-	if p.RefPackages, err = urnsToPackages(gosrc, urns, sources...); err != nil {
+type actorSectionRef struct {
+	Name   string
+	Actors *[]spirit.ActorConfig
+}
+
+// actorSectionRefs returns the plain actor sections of the loaded config,
+// the pools are not included
+func (p *SpiritHelper) actorSectionRefs() []actorSectionRef {
+	return []actorSectionRef{
+		{"input_translators", &p.conf.InputTranslators},
+		{"output_translators", &p.conf.OutputTranslators},
+		{"inboxes", &p.conf.Inboxes},
+		{"outboxes", &p.conf.Outboxes},
+		{"receivers", &p.conf.Receivers},
+		{"senders", &p.conf.Senders},
+		{"routers", &p.conf.Routers},
+		{"components", &p.conf.Components},
+		{"label_matchers", &p.conf.LabelMatchers},
+		{"urn_rewriters", &p.conf.URNRewriters},
+		{"messengers", &p.conf.Messengers},
+	}
+}
+
+// AppendActors appends actors to a section of the loaded config, e.g.:
+// receivers or components, the urns of them are resolved by CreateProject as
+// the others, and the config copied into project is the augmented config,
+// the actors are spliced into the original json document, so the directive
+// and the keys unknown by spirit are kept, the other formats are refused
+// since their comments could not be written back
+func (p *SpiritHelper) AppendActors(section string, actors ...spirit.ActorConfig) (err error) {
+	if p.configErr != nil {
+		err = fmt.Errorf("could not append actors to config %s before its placeholders are rendered, %s", p.configFile, p.configErr)
+		return
+	}
+
+	if format := p.configFormat(); format != ConfigFormatJSON {
+		err = fmt.Errorf("could not append actors to %s config %s, it could not be written back without losing comments, convert it to json first", format, p.configFile)
 		return
 	}
 
+	for _, ref := range p.actorSectionRefs() {
+		if ref.Name != section {
+			continue
+		}
+
+		if p.originalConfig, err = spliceActors(p.originalConfig, section, actors); err != nil {
+			err = fmt.Errorf("append actors to config %s failed, %s", p.configFile, err)
+			return
+		}
+
+		*ref.Actors = append(*ref.Actors, actors...)
+
+		return
+	}
+
+	err = fmt.Errorf("unknown config section: %s", section)
+
 	return
 }
 
-func parseActorsUsingURN(confs ...[]spirit.ActorConfig) (urns []string) {
-	for _, conf := range confs {
-		for _, c := range conf {
-			urns = append(urns, c.URN)
+// spliceActors appends actors to the section of json config data by byte
+// offsets, the other keys, their order and the existing actors are kept as
+// they are, the section missing is added as the last key
+func spliceActors(data []byte, section string, actors []spirit.ActorConfig) (spliced []byte, err error) {
+	var entries []string
+	for _, actor := range actors {
+		var entry []byte
+		if entry, err = json.Marshal(actor); err != nil {
+			return
 		}
+		entries = append(entries, string(entry))
 	}
-	return
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var token json.Token
+	if token, err = decoder.Token(); err != nil {
+		return
+	} else if token != json.Delim('{') {
+		err = fmt.Errorf("the config is not a json object")
+		return
+	}
+
+	hasKeys := false
+
+	for decoder.More() {
+		if token, err = decoder.Token(); err != nil {
+			return
+		}
+		hasKeys = true
+
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return
+		}
+
+		if token != section {
+			continue
+		}
+
+		end := int(decoder.InputOffset())
+		start := end - len(value)
+
+		var existing []json.RawMessage
+		if err = json.Unmarshal(value, &existing); err != nil {
+			return
+		}
+
+		// the new actors are inserted before the closing bracket of the
+		// section, null or empty section is replaced
+		if len(existing) == 0 {
+			return splice(data, start, end, "["+strings.Join(entries, ", ")+"]"), nil
+		}
+
+		closing := start + bytes.LastIndexByte(value, ']')
+		last := len(bytes.TrimRight(data[:closing], " \t\r\n"))
+
+		return splice(data, last, last, ", "+strings.Join(entries, ", ")), nil
+	}
+
+	if _, err = decoder.Token(); err != nil {
+		return
+	}
+
+	closing := int(decoder.InputOffset()) - 1
+	last := len(bytes.TrimRight(data[:closing], " \t\r\n"))
+
+	field := "\n    " + strconv.Quote(section) + ": [" + strings.Join(entries, ", ") + "]\n"
+	if hasKeys {
+		field = "," + field
+	}
+
+	return splice(data, last, closing, field), nil
+}
+
+// splice returns data with data[start:end] replaced by s
+func splice(data []byte, start, end int, s string) []byte {
+	spliced := append([]byte{}, data[:start]...)
+	spliced = append(spliced, s...)
+	return append(spliced, data[end:]...)
 }
 
 func parseActorUsingURN(actorConfs ...spirit.ActorConfig) (urns []string) {
@@ -309,45 +1200,106 @@ func parseActorUsingURN(actorConfs ...spirit.ActorConfig) (urns []string) {
 	return
 }
 
-func urnsToPackages(gosrc string, urns []string, sourceFiles ...string) (packages []Package, err error) {
-	urnPkgMap := map[string]string{}
+// urnSource is a loaded source of urnsToPackages, name is the file of it
+type urnSource struct {
+	name string
+	conf SourceConfig
+}
+
+// urnSources loads the source files, then the sources added by AddSource are
+// appended, the files are loaded from cache if it is not nil
+func (p *SpiritHelper) urnSources(files []string, cache map[string]SourceConfig) (sources []urnSource, err error) {
+	for _, file := range files {
+		sourceConf, exist := cache[file]
+		if !exist {
+			if sourceConf, err = loadSourceConfig(file); err != nil {
+				return
+			}
+			if cache != nil {
+				cache[file] = sourceConf
+			}
+		}
+		sources = append(sources, urnSource{name: file, conf: sourceConf})
+	}
 
-	for _, sourceFile := range sourceFiles {
-		var data []byte
+	for i, sourceConf := range p.memSources {
+		sources = append(sources, urnSource{name: fmt.Sprintf("added source #%d", i), conf: sourceConf})
+	}
 
-		if data, err = ioutil.ReadFile(sourceFile); err != nil {
-			return
+	return
+}
+
+// urnsToPackages resolves the urns to packages by sources and then resolvers, urnPkgs is the
+// package uri of each urn, deprecated are the deprecated urns of sources,
+// if versionSep is not empty, the urn with version suffix, e.g. urn:foo#v2,
+// matches the exact urn first, then the base urn urn:foo
+func urnsToPackages(gosrc string, urns []string, overrides map[string]PackageOverride, versionSep string, resolvers []Resolver, sources []urnSource) (packages []Package, urnPkgs map[string]string, deprecated map[string]URNDeprecation, err error) {
+	urnPkgMap := sourceResolver{}
+	deprecated = map[string]URNDeprecation{}
+
+	for _, source := range sources {
+		for _, urnPkg := range source.conf.Packages {
+			if clash := addURNPackage(urnPkgMap, urnPkg); clash != "" {
+				err = fmt.Errorf("source have duplicate urn pkg, urn:%s, pkg1:%s, pkg2: %s, file: %s", urnPkg.URN, clash, urnPkg.Pkg, source.name)
+				return
+			}
 		}
 
-		sourceConf := SourceConfig{}
-		if err = json.Unmarshal(data, &sourceConf); err != nil {
+		for urn, deprecation := range source.conf.Deprecated {
+			deprecated[urn] = deprecation
+		}
+	}
+
+	// the sources are consulted before the given resolvers
+	resolvers = append([]Resolver{urnPkgMap}, resolvers...)
+
+	pkgs := map[string]string{}
+	urnPkgs = map[string]string{}
+
+	var unresolved []string
+
+	for _, urn := range urns {
+		var pkg, revision string
+		var exist bool
+		if pkg, revision, exist, err = resolveURN(resolvers, urn); err != nil {
 			return
 		}
 
-		for _, urnPkg := range sourceConf.Packages {
-			if oldVal, exist := urnPkgMap[urnPkg.URN]; exist {
-				if oldVal != urnPkg.Pkg {
-					err = fmt.Errorf("source have duplicate urn pkg, urn:%s, pkg1:%s, pkg2: %s, file: %s", urnPkg.URN, oldVal, urnPkg.Pkg, sourceFile)
+		if !exist && versionSep != "" {
+			if i := strings.LastIndex(urn, versionSep); i > 0 {
+				if pkg, revision, exist, err = resolveURN(resolvers, urn[:i]); err != nil {
 					return
 				}
 			}
-			urnPkgMap[urnPkg.URN] = urnPkg.Pkg
 		}
-	}
-
-	pkgs := map[string]bool{}
 
-	for _, urn := range urns {
-		if pkg, exist := urnPkgMap[urn]; !exist {
-			err = fmt.Errorf("no package from any source of urn: %s", urn)
-			return
+		if !exist {
+			if _, exist := urnPkgs[urn]; !exist {
+				unresolved = append(unresolved, urn)
+				urnPkgs[urn] = ""
+			}
+		} else if override, exist := overrides[pkg]; exist {
+			pkgs[override.URI] = override.Revision
+			urnPkgs[urn] = override.URI
 		} else {
-			pkgs[pkg] = true
+			if revision != "" || pkgs[pkg] == "" {
+				pkgs[pkg] = revision
+			}
+			urnPkgs[urn] = pkg
 		}
 	}
 
-	for pkg, _ := range pkgs {
-		packages = append(packages, Package{gosrc: gosrc, URI: pkg, Revision: ""})
+	for pkg, revision := range pkgs {
+		packages = append(packages, Package{gosrc: gosrc, URI: pkg, Revision: revision})
+	}
+
+	sort.Sort(packagesByURI(packages))
+
+	// the packages of resolved urns are returned with the error
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		err = &URNNotResolvedError{URNs: unresolved}
+		return
 	}
 
 	return
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogap/spirit"
+)
+
+func TestGetPackagesRevision(t *testing.T) {
+	goPath, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(goPath)
+
+	fetched := map[string]string{}
+
+	helper := &SpiritHelper{
+		RefPackages: []Package{
+			{URI: "github.com/gogap/spirit", Revision: "master"},
+			{URI: "github.com/gogap/logrus_mate"},
+		},
+		get: func(pkg *Package, update bool) error {
+			fetched[pkg.URI] = pkg.Revision
+			return nil
+		},
+	}
+
+	createOpts := CreateOptions{
+		GoPath: goPath,
+		PackagesRevision: map[string]string{
+			"github.com/gogap/spirit": "v1.0.0",
+			"github.com/gogap/errors": "v2.0.0",
+		},
+	}
+
+	if err = helper.GetPackages(createOpts); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions := map[string]string{}
+	for _, pkg := range helper.RefPackages {
+		revisions[pkg.URI] = pkg.Revision
+	}
+
+	if revision := revisions["github.com/gogap/spirit"]; revision != "v1.0.0" {
+		t.Errorf("revision of RefPackages is %q, want v1.0.0", revision)
+	}
+
+	if revision := fetched["github.com/gogap/spirit"]; revision != "v1.0.0" {
+		t.Errorf("fetched revision is %q, want v1.0.0", revision)
+	}
+
+	if revision := revisions["github.com/gogap/logrus_mate"]; revision != "" {
+		t.Errorf("revision of package not in pkgRevision is %q, want it unchanged", revision)
+	}
+
+	if revision, exist := revisions["github.com/gogap/errors"]; len(helper.RefPackages) != 3 || !exist || revision != "v2.0.0" {
+		t.Errorf("package only in pkgRevision is not appended: %v", helper.RefPackages)
+	}
+}
+
+// jsonKeys returns the top level keys of json object data in order
+func jsonKeys(t *testing.T, data []byte) (keys []string) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		t.Fatal(err)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func TestSpliceActors(t *testing.T) {
+	actors := []spirit.ActorConfig{{Name: "new", URN: "urn:test:new"}}
+
+	cases := []struct {
+		data  string
+		keys  []string
+		names []string
+	}{
+		{
+			data:  `{"spirit_tool": {"template": "classic"}, "components": [{"name": "old", "urn": "urn:test:old"}], "readers": null}`,
+			keys:  []string{"spirit_tool", "components", "readers"},
+			names: []string{"old", "new"},
+		}, {
+			data:  "{\n    \"readers\": [],\n    \"components\": [\n        {\"name\": \"old\"}\n    ],\n    \"writers\": []\n}",
+			keys:  []string{"readers", "components", "writers"},
+			names: []string{"old", "new"},
+		}, {
+			data:  `{"writers": [], "components": null}`,
+			keys:  []string{"writers", "components"},
+			names: []string{"new"},
+		}, {
+			data:  "{\n    \"writers\": []\n}\n",
+			keys:  []string{"writers", "components"},
+			names: []string{"new"},
+		}, {
+			data:  `{}`,
+			keys:  []string{"components"},
+			names: []string{"new"},
+		},
+	}
+
+	for _, c := range cases {
+		spliced, err := spliceActors([]byte(c.data), "components", actors)
+		if err != nil {
+			t.Errorf("splice actors into %s failed: %s", c.data, err)
+			continue
+		}
+
+		if keys := jsonKeys(t, spliced); !reflect.DeepEqual(keys, c.keys) {
+			t.Errorf("keys of %s are %v, want %v", spliced, keys, c.keys)
+		}
+
+		var conf struct {
+			Components []spirit.ActorConfig `json:"components"`
+		}
+		if err = json.Unmarshal(spliced, &conf); err != nil {
+			t.Errorf("spliced config %s is invalid: %s", spliced, err)
+			continue
+		}
+
+		var names []string
+		for _, actor := range conf.Components {
+			names = append(names, actor.Name)
+		}
+
+		if !reflect.DeepEqual(names, c.names) {
+			t.Errorf("actors of %s are %v, want %v", spliced, names, c.names)
+		}
+	}
+
+	// the bytes before the section are kept as they are
+	data := "{\n  \"spirit_tool\" : { \"template\":\"classic\" },\n  \"components\": [{\"name\": \"old\"}]\n}"
+	spliced, err := spliceActors([]byte(data), "components", actors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := data[:strings.Index(data, `"old"}`)+len(`"old"}`)]
+	if !strings.HasPrefix(string(spliced), prefix) {
+		t.Errorf("spliced config %s does not keep the original bytes %s", spliced, prefix)
+	}
+}
+
+const splitGoPathTestTemplate = `package main
+
+import (
+//<-range $_, $pkg := .packages->//	_ "//<-$pkg.URI->//"
+//<-end->//)
+
+func main() {}
+`
+
+func TestBuildProjectAcrossGoPaths(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "spirit-tool.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"template/test/main.go":             splitGoPathTestTemplate,
+		"config.json":                       `{"components": [{"name": "lib", "urn": "urn:test:lib"}]}`,
+		"source.json":                       `{"packages": [{"urn": "urn:test:lib", "pkg": "example.com/lib"}]}`,
+		"gopath/src/example.com/lib/lib.go": "package lib\n",
+		"output/src/example.com/other/x.go": "package other\n",
+	}
+
+	for name, data := range files {
+		filename := path.Join(dir, name)
+		if err = os.MkdirAll(path.Dir(filename), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filename, []byte(data), os.FileMode(0644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	helper, err := NewSpiritHelper(WithTemplateRoot(path.Join(dir, "template")), WithConfig(path.Join(dir, "config.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the project is in output GOPATH, the package it imports is in GoPath
+	createOpts := CreateOptions{
+		TemplateName: "test",
+		GoPath:       path.Join(dir, "gopath"),
+		OutputGoPath: path.Join(dir, "output"),
+		ProjectPath:  "example.com/app",
+		Sources:      []string{path.Join(dir, "source.json")},
+		VetGenerated: true,
+	}
+
+	binPath := path.Join(dir, "bin", "app")
+
+	if err = helper.BuildProject(createOpts, binPath, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(binPath); err != nil {
+		t.Errorf("binary is not built: %s", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SectionSummary is the actor count and the distinct urns of a config section
+type SectionSummary struct {
+	Name   string   `json:"name"`
+	Actors int      `json:"actors"`
+	URNs   []string `json:"urns"`
+}
+
+func (p SectionSummary) String() string {
+	if len(p.URNs) == 0 {
+		return fmt.Sprintf("%s: %d", p.Name, p.Actors)
+	}
+	return fmt.Sprintf("%s: %d (%s)", p.Name, p.Actors, strings.Join(p.URNs, ", "))
+}
+
+// Summary returns the sections of the loaded config in the order parse scans
+// them, it needs no sources
+func (p *SpiritHelper) Summary() (summaries []SectionSummary) {
+	for _, section := range p.actorSections() {
+		summary := SectionSummary{Name: section.Name, Actors: len(section.Actors)}
+
+		found := map[string]bool{}
+		for _, actor := range section.Actors {
+			if actor.URN != "" && !found[actor.URN] {
+				found[actor.URN] = true
+				summary.URNs = append(summary.URNs, actor.URN)
+			}
+		}
+
+		sort.Strings(summary.URNs)
+
+		summaries = append(summaries, summary)
+	}
+
+	return
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"text/template"
+)
+
+const systemdUnitTemplate = `[Unit]
+Description=spirit service {{.Name}}
+After=network.target
+
+[Service]
+Type=simple
+# the config is read from working directory: {{.ConfigPath}}
+WorkingDirectory={{.WorkingDirectory}}
+ExecStart={{.ExecStart}}
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// systemdUnit is the data of systemd unit template
+type systemdUnit struct {
+	Name             string
+	WorkingDirectory string
+	ExecStart        string
+	ConfigPath       string
+}
+
+// systemdServiceName returns the name of systemd unit, default is the base
+// name of project
+func (p *CreateOptions) systemdServiceName() string {
+	if p.ServiceName != "" {
+		return p.ServiceName
+	}
+	return path.Base(p.projectDir())
+}
+
+// systemdUnitFile returns the systemd unit of project, it is written into the
+// project dir, the ExecStart is the binary built by BuildProject
+func (p *SpiritHelper) systemdUnitFile(createOpts CreateOptions) (file generatedFile, err error) {
+	projectPath := createOpts.projectDir()
+
+	unit := systemdUnit{
+		Name:             createOpts.systemdServiceName(),
+		WorkingDirectory: projectPath,
+		ExecStart:        createOpts.binaryPath("main"),
+		ConfigPath:       path.Join(projectPath, p.projectConfigFileName(createOpts)),
+	}
+
+	if createOpts.Layout == LayoutFHS {
+		unit.WorkingDirectory = createOpts.fhsConfigDir()
+		unit.ExecStart = createOpts.fhsBinaryPath()
+		unit.ConfigPath = path.Join(createOpts.fhsConfigDir(), p.projectConfigFileName(createOpts))
+	}
+
+	var tmpl *template.Template
+	if tmpl, err = template.New("systemd").Parse(systemdUnitTemplate); err != nil {
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, unit); err != nil {
+		return
+	}
+
+	file = generatedFile{Name: unit.Name + ".service", Data: buffer.Bytes()}
+
+	return
+}
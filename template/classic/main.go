@@ -18,8 +18,19 @@ const TemplateVersion = "0.0.1"
 
 const CreateTime = `//<-printf "%s" .create_time->//`
 
+// the version of spirit-tool which generated this file
+var CreateToolVersion string //<-printf "= \"%s\"" .tool_version->//
+
 var configFile string //<-printf "= \"%s\"" .config_filename->//
 
+// build info, set by spirit-tool with -ldflags -X when --version-var is main
+var (
+	BuildTime        string
+	BuildConfig      string
+	BuildToolVersion string
+	BuildRevisions   string
+)
+
 var (
 	innerConfig bool //<-printf "= %v" .args.inner_config->//
 
@@ -36,6 +47,12 @@ func main() {
 		}
 	}()
 
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		fmt.Printf("template: %s\ncreate time: %s\ncreate by spirit-tool: %s\nbuild time: %s\nconfig: %s\nspirit-tool: %s\npackages: %s\n",
+			TemplateVersion, CreateTime, CreateToolVersion, BuildTime, BuildConfig, BuildToolVersion, BuildRevisions)
+		return
+	}
+
 	if configFile != "" {
 		var fileData []byte
 		if fileData, err = ioutil.ReadFile(configFile); err != nil {
@@ -88,5 +105,5 @@ func main() {
 }
 
 var (
-	config string //<-if .args.inner_config->////<-printf "= `%s`" .config->////<-end->//
+	config string //<-if .args.inner_config->////<-printf "= `%s`" .config_content->////<-end->//
 )
@@ -0,0 +1,62 @@
+//<-if false->//
+//go:build ignore
+// +build ignore
+
+// the template uses embeddedConfig of the file generated by --embed-config,
+// it is ignored by go build
+//<-end->//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gogap/spirit"
+)
+
+//<-printf "import ("->//
+//<-range $_, $pkg := .packages->////<-printf "\t_ \"%s\"\n" $pkg.URI->////<-end->////<-printf ")"->//
+
+const CreateTime = `//<-printf "%s" .create_time->//`
+
+func main() {
+	var err error
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	// embeddedConfig is compiled in, no config file is read
+	spiritConf := spirit.SpiritConfig{}
+	if err = json.Unmarshal(embeddedConfig, &spiritConf); err != nil {
+		return
+	}
+
+	if err = spiritConf.Validate(); err != nil {
+		err = fmt.Errorf("spirit config validate failed, %s", err)
+		return
+	}
+
+	var sp spirit.Spirit
+	if sp, err = spirit.NewClassicSpirit(); err != nil {
+		err = fmt.Errorf("create new classic spirit error, %s", err)
+		return
+	}
+
+	if err = sp.Build(spiritConf); err != nil {
+		err = fmt.Errorf("build classic spirit error, %s", err)
+		return
+	}
+
+	var wg *sync.WaitGroup
+	if wg, err = sp.Run(); err != nil {
+		return
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,64 @@
+//<-if false->//
+//go:build ignore
+// +build ignore
+
+// the template is not a complete program, it is ignored by go build
+//<-end->//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/gogap/spirit"
+)
+
+//<-printf "import ("->//
+//<-range $_, $pkg := .packages->////<-printf "\t_ \"%s\"\n" $pkg.URI->////<-end->////<-printf ")"->//
+
+const SpiritCreateTime = `//<-printf "%s" .create_time->//`
+
+// SpiritConfigFile is the spirit config read by RunSpirit
+var SpiritConfigFile string //<-printf "= \"%s\"" .config_filename->//
+
+// RunSpirit builds the spirit of SpiritConfigFile and runs it, it blocks
+// until the spirit stopped, call it from your own main
+func RunSpirit() (err error) {
+	var fileData []byte
+	if fileData, err = ioutil.ReadFile(SpiritConfigFile); err != nil {
+		return
+	}
+
+	spiritConf := spirit.SpiritConfig{}
+	if err = json.Unmarshal(fileData, &spiritConf); err != nil {
+		return
+	}
+
+	if err = spiritConf.Validate(); err != nil {
+		err = fmt.Errorf("spirit config validate failed, %s", err)
+		return
+	}
+
+	var sp spirit.Spirit
+	if sp, err = spirit.NewClassicSpirit(); err != nil {
+		err = fmt.Errorf("create new classic spirit error, %s", err)
+		return
+	}
+
+	if err = sp.Build(spiritConf); err != nil {
+		err = fmt.Errorf("build classic spirit error, %s", err)
+		return
+	}
+
+	var wg *sync.WaitGroup
+	if wg, err = sp.Run(); err != nil {
+		return
+	}
+
+	wg.Wait()
+
+	return
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// templateData returns the data passed into template
+func (p *SpiritHelper) templateData(createOpts CreateOptions, args map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"create_options":  createOpts,
+		"packages":        p.RefPackages,
+		"config":          createOpts.configRuntimePath(p.projectConfigFileName(createOpts)),
+		"source_config":   p.configFile,
+		"spirit_config":   p.conf,
+		"config_filename": p.projectConfigFileName(createOpts),
+		"config_content":  string(p.configContent),
+		"embed_config":    createOpts.EmbedConfig,
+		"create_time":     p.createTime,
+		"tool_version":    version,
+		"unresolved_urns": p.unresolvedURNs,
+		"args":            args,
+	}
+}
+
+// PrintTemplateData writes the data which template would receive to w as
+// json, the template is not executed and no package is fetched
+func (p *SpiritHelper) PrintTemplateData(w io.Writer, createOpts CreateOptions, tmplArgs map[string]interface{}) (err error) {
+	// nothing is fetched, so GOPATH/src is not created
+	createOpts.GetPackages = false
+
+	if _, err = p.loadProject(&createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	if err = p.prepareRender(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	var args map[string]interface{}
+	if args, err = p.templateArgs(createOpts, tmplArgs); err != nil {
+		return
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(p.templateData(createOpts, args), "", "    "); err != nil {
+		return
+	}
+
+	if _, err = w.Write(append(data, '\n')); err != nil {
+		return
+	}
+
+	return
+}
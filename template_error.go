@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"strconv"
+)
+
+// templateErrorLines is the number of template source lines shown before and
+// after the failed line
+const templateErrorLines = 2
+
+var templateErrorPosRegexp = regexp.MustCompile(`^template: [^:]+:(\d+)(?::(\d+))?:`)
+
+// withTemplateSource appends the template source around the position of
+// text/template error, it is hard to find with the //<- ->// delims
+func withTemplateSource(err error, source []byte) error {
+	matches := templateErrorPosRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+	col, _ := strconv.Atoi(matches[2])
+
+	if line < 1 || line > bytes.Count(source, []byte("\n"))+1 {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s\n", err)
+
+	writeSourceExcerpt(buf, source, line, col)
+
+	return fmt.Errorf("%s", buf.String())
+}
+
+// writeSourceExcerpt writes the source lines around line, col marks the
+// column of the line if it is not zero
+func writeSourceExcerpt(buf *bytes.Buffer, source []byte, line int, col int) {
+	lines := bytes.Split(source, []byte("\n"))
+
+	for i := line - templateErrorLines; i <= line+templateErrorLines; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+
+		fmt.Fprintf(buf, "%s %4d | %s\n", marker, i, lines[i-1])
+
+		if i == line && col > 0 {
+			fmt.Fprintf(buf, "  %4s | %s^\n", "", bytes.Repeat([]byte(" "), col-1))
+		}
+	}
+}
+
+// checkGoSyntax parses the rendered go source of template file in memory, the
+// error shows the rendered lines around the first syntax error
+func checkGoSyntax(file templateFile, src []byte) error {
+	fset := token.NewFileSet()
+
+	_, err := parser.ParseFile(fset, file.Name, src, parser.AllErrors)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(scanner.ErrorList)
+	if !ok || len(errs) == 0 {
+		return fmt.Errorf("rendered %s of template %s is not valid go, %s", file.Name, file.Path, err)
+	}
+
+	first := errs[0]
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "rendered %s of template %s is not valid go, %d errors, first at line %d column %d: %s\n",
+		file.Name, file.Path, len(errs), first.Pos.Line, first.Pos.Column, first.Msg)
+
+	writeSourceExcerpt(buf, src, first.Pos.Line, first.Pos.Column)
+
+	return fmt.Errorf("%s", buf.String())
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateExt is the extension of the template files besides main.go, they
+// are rendered into project without it, e.g. metrics.go.tmpl to metrics.go
+const templateExt = ".tmpl"
+
+// frontMatterDelim opens and closes the optional front-matter of template
+// file, e.g.:
+//
+//	// ---
+//	// emit: .args.metrics
+//	// ---
+//
+// emit is a template pipeline evaluated with the template data, the file is
+// not written into project if it is false
+const frontMatterDelim = "// ---"
+
+// delimsKey is the front-matter key of the template delims of file, e.g.:
+//
+//	// delims: [[ ]]
+//
+// it is useful for the file whose content collides with the default delims
+const delimsKey = "delims"
+
+const (
+	defaultLeftDelim  = "//<-"
+	defaultRightDelim = "->//"
+)
+
+// templateFile is a file of template dir, it is rendered into project as
+// Name
+type templateFile struct {
+	Path        string
+	Name        string
+	Source      []byte
+	FrontMatter map[string]string
+}
+
+// generatedFile is a rendered template file
+type generatedFile struct {
+	Name string
+	Data []byte
+}
+
+// outputNamesArg is the reserved arg of output name overrides, key is the
+// template file name, e.g. in args.json:
+//
+//	"output_names": {"Dockerfile.tmpl": "Dockerfile.prod"}
+const outputNamesArg = "output_names"
+
+// templateFiles returns main.go and the *.tmpl files of template dir, the
+// output name is the override of output_names arg, or the file name without
+// .tmpl, main.go is rendered into createOpts.OutputFileName if it is set
+func (p *SpiritHelper) templateFiles(createOpts CreateOptions, args map[string]interface{}) (files []templateFile, err error) {
+	dir := createOpts.templateDir()
+
+	var tmpls []string
+	if tmpls, err = filepath.Glob(path.Join(dir, "*"+templateExt)); err != nil {
+		return
+	}
+
+	var overrides map[string]interface{}
+	if v, exist := args[outputNamesArg]; exist {
+		var ok bool
+		if overrides, ok = v.(map[string]interface{}); !ok {
+			err = fmt.Errorf("arg %s should be a map of template file name to output name", outputNamesArg)
+			return
+		}
+	}
+
+	paths := append([]string{path.Join(dir, "main.go")}, tmpls...)
+	names := map[string]string{}
+
+	for _, filename := range paths {
+		base := path.Base(filename)
+		names[filename] = strings.TrimSuffix(base, templateExt)
+
+		if v, exist := overrides[base]; exist {
+			name, ok := v.(string)
+			if !ok || name == "" || path.IsAbs(name) || strings.HasPrefix(path.Clean(name), "..") {
+				err = fmt.Errorf("output name of %s should be a relative path in project: %v", base, v)
+				return
+			}
+			names[filename] = name
+		}
+	}
+
+	if createOpts.OutputFileName != "" {
+		names[path.Join(dir, "main.go")] = createOpts.OutputFileName
+	}
+
+	for _, filename := range paths {
+		var data []byte
+		if data, err = ioutil.ReadFile(filename); err != nil {
+			return
+		}
+
+		file := templateFile{Path: filename, Name: names[filename]}
+		if file.FrontMatter, file.Source, err = parseFrontMatter(data); err != nil {
+			err = fmt.Errorf("parse front-matter of %s failed, %s", filename, err)
+			return
+		}
+
+		files = append(files, file)
+	}
+
+	return
+}
+
+// parseFrontMatter splits the leading front-matter of key: value lines from
+// the template source
+func parseFrontMatter(data []byte) (frontMatter map[string]string, body []byte, err error) {
+	frontMatter = map[string]string{}
+	body = data
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if strings.TrimSpace(lines[0]) != frontMatterDelim {
+		return
+	}
+
+	offset := len(lines[0])
+
+	for _, line := range lines[1:] {
+		offset += len(line)
+
+		if strings.TrimSpace(line) == frontMatterDelim {
+			body = data[offset:]
+			return
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(line), "//"), ":", 2)
+		if len(kv) != 2 {
+			err = fmt.Errorf("front-matter line should be `// key: value`: %s", strings.TrimSpace(line))
+			return
+		}
+
+		frontMatter[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	err = fmt.Errorf("front-matter is not closed by %s", frontMatterDelim)
+
+	return
+}
+
+// shouldEmit evaluates the emit condition of front-matter with data
+func (p *templateFile) shouldEmit(data map[string]interface{}) (emit bool, err error) {
+	cond, exist := p.FrontMatter["emit"]
+	if !exist {
+		return true, nil
+	}
+
+	var tmpl *template.Template
+	if tmpl, err = template.New(p.Name).Funcs(templateFuncs).Parse("{{if " + cond + "}}true{{end}}"); err != nil {
+		err = fmt.Errorf("emit condition of %s is invalid, %s", p.Path, err)
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tmpl.Execute(buffer, data); err != nil {
+		err = fmt.Errorf("evaluate emit condition of %s failed, %s", p.Path, err)
+		return
+	}
+
+	emit = buffer.String() == "true"
+
+	return
+}
+
+// delims returns the delims of front-matter, default is //<- and ->//
+func (p *templateFile) delims() (left, right string, err error) {
+	value, exist := p.FrontMatter[delimsKey]
+	if !exist {
+		return defaultLeftDelim, defaultRightDelim, nil
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		err = fmt.Errorf("delims of %s should be the left and right delims separated by space: %s", p.Path, value)
+		return
+	}
+
+	return fields[0], fields[1], nil
+}
+
+// parse parses the template source with the delims of front-matter
+func (p *templateFile) parse() (tmpl *template.Template, err error) {
+	var left, right string
+	if left, right, err = p.delims(); err != nil {
+		return
+	}
+
+	if tmpl, err = template.New(p.Name).Funcs(templateFuncs).Option("missingkey=error").Delims(left, right).Parse(string(p.Source)); err != nil {
+		err = withTemplateSource(err, p.Source)
+		return
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	cases := []struct {
+		data        string
+		frontMatter map[string]string
+		body        string
+		invalid     bool
+	}{
+		{
+			data:        "package main\n",
+			frontMatter: map[string]string{},
+			body:        "package main\n",
+		}, {
+			data:        "// ---\n// emit: .args.systemd\n//delims:  [[ ]]  \n// ---\npackage main\n",
+			frontMatter: map[string]string{"emit": ".args.systemd", "delims": "[[ ]]"},
+			body:        "package main\n",
+		}, {
+			data:        "// ---\r\n// emit: eq .args.env \"prod:1\"\r\n// ---\r\n",
+			frontMatter: map[string]string{"emit": "eq .args.env \"prod:1\""},
+			body:        "",
+		}, {
+			data:        "package main\n// ---\n// emit: false\n// ---\n",
+			frontMatter: map[string]string{},
+			body:        "package main\n// ---\n// emit: false\n// ---\n",
+		}, {
+			data:    "// ---\n// emit .args.systemd\n// ---\n",
+			invalid: true,
+		}, {
+			data:    "// ---\n// emit: .args.systemd\npackage main\n",
+			invalid: true,
+		},
+	}
+
+	for _, c := range cases {
+		frontMatter, body, err := parseFrontMatter([]byte(c.data))
+		if c.invalid {
+			if err == nil {
+				t.Errorf("front-matter of %q should be invalid", c.data)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parse front-matter of %q failed: %s", c.data, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(frontMatter, c.frontMatter) || string(body) != c.body {
+			t.Errorf("front-matter of %q is %v and body %q, want %v and %q", c.data, frontMatter, body, c.frontMatter, c.body)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helpers available to templates besides the builtin
+// functions of text/template, they are stable for template authors:
+//
+//	quoteGo   quotes the value as go string literal, e.g. //<-quoteGo .config->//
+//	b64enc    encodes the value by standard base64
+//	env       returns the environment variable of the name
+//	hasPrefix reports whether the string begins with prefix
+var templateFuncs = template.FuncMap{
+	"quoteGo":   strconv.Quote,
+	"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"env":       os.Getenv,
+	"hasPrefix": strings.HasPrefix,
+}
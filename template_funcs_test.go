@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	defer os.Setenv("SPIRIT_TOOL_TEST_ENV", os.Getenv("SPIRIT_TOOL_TEST_ENV"))
+	os.Setenv("SPIRIT_TOOL_TEST_ENV", "from env")
+
+	cases := []struct {
+		tmpl     string
+		expected string
+	}{
+		{`var s = //<-quoteGo .value->//`, `var s = "say \"hi\"\n\tbye\\"`},
+		{`//<-quoteGo ""->//`, `""`},
+		{`//<-b64enc .value->//`, `c2F5ICJoaSIKCWJ5ZVw=`},
+		{`//<-b64enc ""->//`, ``},
+		{`//<-env "SPIRIT_TOOL_TEST_ENV"->//`, `from env`},
+		{`//<-env "SPIRIT_TOOL_TEST_ENV_NOT_SET"->//`, ``},
+		{`//<-if hasPrefix .url "https://"->//secure//<-else->//insecure//<-end->//`, `secure`},
+		{`//<-if hasPrefix .value "https://"->//secure//<-else->//insecure//<-end->//`, `insecure`},
+		{`//<-.url | quoteGo | b64enc->//`, `Imh0dHBzOi8vZXhhbXBsZS5jb20i`},
+	}
+
+	data := map[string]interface{}{
+		"value": "say \"hi\"\n\tbye\\",
+		"url":   "https://example.com",
+	}
+
+	for _, c := range cases {
+		tmpl, err := template.New("test").Funcs(templateFuncs).Delims(defaultLeftDelim, defaultRightDelim).Parse(c.tmpl)
+		if err != nil {
+			t.Errorf("parse %s failed: %s", c.tmpl, err)
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, data); err != nil {
+			t.Errorf("execute %s failed: %s", c.tmpl, err)
+			continue
+		}
+
+		if buf.String() != c.expected {
+			t.Errorf("%s is rendered as %q, want %q", c.tmpl, buf.String(), c.expected)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// templateScaffoldMain is the starter main.go of new template, it lists the
+// data which could be used in template
+const templateScaffoldMain = `package main
+
+// template data, delims are //<- and ->//:
+//   .packages        the packages resolved from urns, each has .URI and .Revision
+//   .config          the config path of the deployed project, --config-path
+//   .source_config   the config file path when created
+//   .config_filename the config file name copied into project
+//   .config_content  the content of config copied into project
+//   .embed_config    whether the config is compiled in as embeddedConfig
+//   .spirit_config   the parsed spirit config
+//   .create_options  the create options
+//   .create_time     the create time
+//   .tool_version    the version of spirit-tool which generates the code
+//   .unresolved_urns the urns skipped by --allow-unresolved
+//   .args            the args of args.json, --args-file, -a and --args-env-prefix
+//
+// the *.tmpl files beside main.go are rendered into project without .tmpl,
+// a file starting with front-matter is emitted only if emit is true, and
+// it is rendered with the delims of front-matter if they are given:
+//   // ---
+//   // emit: .args.metrics
+//   // delims: [[ ]]
+//   // ---
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gogap/spirit"
+)
+
+//<-printf "import ("->//
+//<-range $_, $pkg := .packages->////<-printf "\t_ \"%s\"\n" $pkg.URI->////<-end->////<-printf ")"->//
+
+const CreateTime = //<-printf "%q" .create_time->//
+
+var configFile string //<-printf "= %q" .config_filename->//
+
+var greeting string //<-printf "= %q" .args.greeting->//
+
+func main() {
+	var err error
+	defer func() {
+		if err != nil {
+			spirit.Logger().Error(err)
+			os.Exit(128)
+		}
+	}()
+
+	fmt.Println(greeting, configFile, CreateTime)
+}
+`
+
+// templateScaffoldArgs is the starter args.json of new template
+const templateScaffoldArgs = `{
+	"greeting": "hello spirit"
+}
+`
+
+// NewTemplate scaffolds the template name under the template root with a
+// starter main.go and args.json, it returns the dir of template
+func (p *SpiritHelper) NewTemplate(goPath, name string, force bool) (dir string, err error) {
+	if name == "" {
+		err = fmt.Errorf("template name is empty")
+		return
+	}
+
+	opts := CreateOptions{GoPath: goPath, TemplateName: name, templateRoot: p.templateRoot}
+	dir = opts.templateDir()
+
+	if _, e := os.Stat(path.Join(dir, "main.go")); e == nil && !force {
+		err = fmt.Errorf("template %s already exist: %s", name, dir)
+		return
+	}
+
+	if err = os.MkdirAll(dir, os.FileMode(0755)); err != nil {
+		return
+	}
+
+	if err = ioutil.WriteFile(path.Join(dir, "main.go"), []byte(templateScaffoldMain), os.FileMode(0644)); err != nil {
+		return
+	}
+
+	if err = ioutil.WriteFile(path.Join(dir, "args.json"), []byte(templateScaffoldArgs), os.FileMode(0644)); err != nil {
+		return
+	}
+
+	p.logger().Infof("template %s created: %s", name, dir)
+
+	return
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	PhaseValidate    = "validate"
+	PhaseParse       = "parse"
+	PhaseGetPackages = "get_packages"
+	PhaseRender      = "render"
+	PhaseWrite       = "write"
+	PhaseFormat      = "go_fmt"
+	PhaseVendor      = "vendor"
+	PhaseVet         = "go_vet"
+	PhaseLock        = "lock"
+)
+
+// PhaseTiming is the duration of a phase of CreateProject, Failed is true
+// if CreateProject failed in the phase
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"duration"`
+	Failed   bool          `json:"failed"`
+}
+
+// startPhase ends the current phase and starts the phase
+func (p *SpiritHelper) startPhase(phase string) {
+	p.endPhase(false)
+	p.phase = phase
+	p.phaseStart = time.Now()
+}
+
+// endPhase records the timing of current phase
+func (p *SpiritHelper) endPhase(failed bool) {
+	if p.phase == "" {
+		return
+	}
+
+	p.Timings = append(p.Timings, PhaseTiming{Phase: p.phase, Duration: time.Since(p.phaseStart), Failed: failed})
+	p.phase = ""
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// UpdateConfig copies the loaded config into the existing project again, the
+// template is not rendered and no package is fetched, the embedded config is
+// rewritten too if createOpts.EmbedConfig is set, which needs rebuild, if pid
+// is not zero, the running project is sent SIGHUP to reload
+func (p *SpiritHelper) UpdateConfig(createOpts CreateOptions, pid int) (err error) {
+	if createOpts.ProjectPath == "" {
+		err = ErrProjectDirIsEmpty
+		return
+	}
+
+	projectPath := createOpts.projectDir()
+
+	if fi, e := os.Stat(path.Join(projectPath, createOpts.outputFileName())); e != nil || fi.IsDir() {
+		err = fmt.Errorf("project %s not exist, create it first", projectPath)
+		return
+	}
+
+	if p.configContent, err = p.projectConfig(createOpts); err != nil {
+		return
+	}
+
+	if _, err = p.writeProjectFile(createOpts, projectPath, p.projectConfigFileName(createOpts), p.configContent); err != nil {
+		return
+	}
+
+	p.logger().Infof("config of project %s is updated: %s", projectPath, p.projectConfigFileName(createOpts))
+
+	if createOpts.EmbedConfig {
+		if _, err = p.writeProjectFile(createOpts, projectPath, embeddedConfigFileName, p.embeddedConfigData()); err != nil {
+			return
+		}
+		p.logger().Warnf("the embedded config of project %s is updated, rebuild the project to apply it", projectPath)
+	}
+
+	if pid != 0 {
+		if err = reloadProcess(pid); err != nil {
+			err = fmt.Errorf("reload process %d failed, %s", pid, err)
+			return
+		}
+	}
+
+	return
+}
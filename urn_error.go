@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// ErrURNNotResolved is matched by errors.Is for URNNotResolvedError
+var ErrURNNotResolved = errors.New("urn not resolved")
+
+// URNNotResolvedError is returned when urns have no package in any source,
+// Origins are the config sections each urn is used in
+type URNNotResolvedError struct {
+	URNs    []string
+	Origins map[string][]string
+}
+
+func (p *URNNotResolvedError) Error() string {
+	var urns []string
+	for _, urn := range p.URNs {
+		if sections := p.Origins[urn]; len(sections) > 0 {
+			urns = append(urns, fmt.Sprintf("%s (used in %s)", urn, strings.Join(sections, ", ")))
+			continue
+		}
+		urns = append(urns, urn)
+	}
+
+	return fmt.Sprintf("no package from any source of urns: %s", strings.Join(urns, "; "))
+}
+
+func (p *URNNotResolvedError) Is(target error) bool {
+	return target == ErrURNNotResolved
+}
+
+// unresolvedURNsComment returns the TODO comment of the generated code which
+// lists the urns skipped by AllowUnresolvedURNs
+func unresolvedURNsComment(urns []string) string {
+	comment := "// TODO: the urns are not resolved, their packages are not imported:\n"
+	for _, urn := range urns {
+		comment += "//   " + urn + "\n"
+	}
+	return comment
+}
+
+// insertUnresolvedURNsComment inserts the TODO comment of urns after the
+// package clause of src, so it is not taken as the package doc, it is put on
+// the top of src if src could not be parsed
+func insertUnresolvedURNsComment(src []byte, urns []string) []byte {
+	comment := []byte(unresolvedURNsComment(urns))
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return append(append(comment, '\n'), src...)
+	}
+
+	offset := fset.Position(f.Name.End()).Offset
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		offset += i + 1
+	} else {
+		src = append(src, '\n')
+		offset = len(src)
+	}
+
+	data := append([]byte{}, src[:offset]...)
+	data = append(data, '\n')
+	data = append(data, comment...)
+
+	return append(data, src[offset:]...)
+}
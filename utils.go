@@ -1,12 +1,30 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 )
 
+// ExitError is returned by RunProject when the project exits with non-zero
+// code, Code is -1 if the project is terminated by signal
+type ExitError struct {
+	Code int
+	Err  *exec.ExitError
+}
+
+func (p *ExitError) Error() string {
+	return fmt.Sprintf("project exited with code %d, %s", p.Code, p.Err)
+}
+
+func (p *ExitError) Unwrap() error {
+	return p.Err
+}
+
 func execCommand(cmd string) (out []byte, err error) {
 	parts := strings.Fields(cmd)
 	command := parts[0]
@@ -30,11 +48,67 @@ func execCommandWithDir(cmd string, dir string) (out []byte, err error) {
 	return
 }
 
-func execute(cmd string, dir string, bindSTD bool, envs []string) (cmder *exec.Cmd, err error) {
+func execCommandWithEnv(cmd string, dir string, envs []string) (out []byte, err error) {
+	parts := strings.Fields(cmd)
+	command := parts[0]
+	args := parts[1:len(parts)]
+
+	cmder := exec.Command(command, args...)
+	cmder.Dir = dir
+	cmder.Env = append(os.Environ(), envs...)
+
+	out, err = cmder.CombinedOutput()
+
+	return
+}
+
+// execCommandArgs runs the command with args as is, unlike execCommand the
+// args are not split by spaces
+func execCommandArgs(dir string, envs []string, command string, args ...string) (out []byte, err error) {
+	return execCommandArgsContext(context.Background(), dir, envs, command, args...)
+}
+
+// commandWaitDelay bounds the wait for the output of the killed command, the
+// sub processes of it, e.g. compile of `go build`, may still hold the pipes
+const commandWaitDelay = 2 * time.Second
+
+// execCommandArgsContext is execCommandArgs which is killed when ctx is done,
+// it returns in commandWaitDelay after that
+func execCommandArgsContext(ctx context.Context, dir string, envs []string, command string, args ...string) (out []byte, err error) {
+	cmder := exec.CommandContext(ctx, command, args...)
+	cmder.Dir = dir
+	cmder.Env = append(os.Environ(), envs...)
+	cmder.WaitDelay = commandWaitDelay
+
+	out, err = cmder.CombinedOutput()
+
+	return
+}
+
+// execCommandContext is execCommandWithEnv which is killed when ctx is done,
+// it returns in commandWaitDelay after that
+func execCommandContext(ctx context.Context, cmd string, dir string, envs []string) (out []byte, err error) {
 	parts := strings.Fields(cmd)
 	command := parts[0]
 	args := parts[1:len(parts)]
 
+	cmder := exec.CommandContext(ctx, command, args...)
+	cmder.Dir = dir
+	cmder.Env = append(os.Environ(), envs...)
+	cmder.WaitDelay = commandWaitDelay
+
+	out, err = cmder.CombinedOutput()
+
+	return
+}
+
+// execute starts cmd, programArgs are appended to the args of cmd as they are
+// without splitting
+func execute(cmd string, dir string, bindSTD bool, envs []string, programArgs ...string) (cmder *exec.Cmd, err error) {
+	parts := strings.Fields(cmd)
+	command := parts[0]
+	args := append(parts[1:len(parts)], programArgs...)
+
 	commander := exec.Command(command, args...)
 
 	if dir != "" {
@@ -58,6 +132,23 @@ func execute(cmd string, dir string, bindSTD bool, envs []string) (cmder *exec.C
 	return
 }
 
+func envArgs(prefix string) (args map[string]string) {
+	args = map[string]string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, prefix) {
+			continue
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(env, prefix), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+
+		args[kv[0]] = kv[1]
+	}
+	return
+}
+
 func killProcess(pid int) (err error) {
 	err = syscall.Kill(pid, syscall.SIGKILL)
 	return
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestExecCommandArgsContextTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the sleep started by sh holds the output pipe after sh is killed
+	start := time.Now()
+	if _, err := execCommandArgsContext(ctx, "", nil, "sh", "-c", "sleep 30; echo done"); err == nil {
+		t.Errorf("the command is not killed on timeout")
+	}
+
+	if elapsed := time.Since(start); elapsed > commandWaitDelay+5*time.Second {
+		t.Errorf("the killed command returns after %s, want in %s", elapsed, commandWaitDelay)
+	}
+}
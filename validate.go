@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogap/spirit"
+)
+
+// Validate checks the loaded spirit config for mistakes which spirit itself
+// accepts silently, such as two actors with the same name or urn in one
+// section
+func (p *SpiritHelper) Validate() (err error) {
+	if p.configErr != nil {
+		err = fmt.Errorf("parse config %s failed, render its placeholders by --template-config, %s", p.configFile, p.configErr)
+		return
+	}
+
+	var clashes []string
+
+	for _, section := range p.actorSections() {
+		clashes = append(clashes, duplicateActors(section)...)
+	}
+
+	clashes = append(clashes, p.poolClashes()...)
+
+	if len(clashes) > 0 {
+		err = fmt.Errorf("duplicate actors in config %s: %s", p.configFile, strings.Join(clashes, "; "))
+		return
+	}
+
+	return
+}
+
+// poolClashes returns the pools without reader or writer, the reader or
+// writer urns shared by pools are only warned, they may differ in options
+func (p *SpiritHelper) poolClashes() (clashes []string) {
+	readerPools := map[string][]string{}
+	for _, pool := range p.conf.ReaderPools {
+		if pool.Reader == nil || pool.Reader.URN == "" {
+			clashes = append(clashes, fmt.Sprintf("reader pool %s (%s) has no reader", pool.Name, pool.URN))
+			continue
+		}
+		readerPools[pool.Reader.URN] = append(readerPools[pool.Reader.URN], pool.Name)
+	}
+
+	writerPools := map[string][]string{}
+	for _, pool := range p.conf.WriterPools {
+		if pool.Writer == nil || pool.Writer.URN == "" {
+			clashes = append(clashes, fmt.Sprintf("writer pool %s (%s) has no writer", pool.Name, pool.URN))
+			continue
+		}
+		writerPools[pool.Writer.URN] = append(writerPools[pool.Writer.URN], pool.Name)
+	}
+
+	for kind, pools := range map[string]map[string][]string{"reader": readerPools, "writer": writerPools} {
+		for urn, names := range pools {
+			if len(names) > 1 {
+				p.logger().Warnf("%s %s is used by %s pools: %s", kind, urn, kind, strings.Join(names, ", "))
+			}
+		}
+	}
+
+	return
+}
+
+// duplicateActors returns the actors of section which share the name, the
+// actor id of spirit, or the urn with an earlier actor, naming the clashed key
+func duplicateActors(section actorSection) (clashes []string) {
+	names := map[string]spirit.ActorConfig{}
+	urns := map[string]spirit.ActorConfig{}
+
+	for _, actor := range section.Actors {
+		if actor.Name != "" {
+			if exist, ok := names[actor.Name]; ok {
+				clashes = append(clashes,
+					fmt.Sprintf("section %s has duplicate actor name %s, urn1: %s, urn2: %s", section.Name, actor.Name, exist.URN, actor.URN))
+			} else {
+				names[actor.Name] = actor
+			}
+		}
+
+		if actor.URN != "" {
+			if exist, ok := urns[actor.URN]; ok {
+				clashes = append(clashes,
+					fmt.Sprintf("section %s has duplicate actor urn %s, name1: %s, name2: %s", section.Name, actor.URN, exist.Name, actor.Name))
+			} else {
+				urns[actor.URN] = actor
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateURNContexts returns error if one urn is used in more than one kind
+// of config section, e.g. both as a receiver and a component, which usually
+// is a mistake, the urns in whitelist are allowed to be reused
+func (p *SpiritHelper) ValidateURNContexts(whitelist ...string) (err error) {
+	allowed := map[string]bool{}
+	for _, urn := range whitelist {
+		allowed[urn] = true
+	}
+
+	urnSections := p.urnSections()
+
+	var urns []string
+	for urn, sections := range urnSections {
+		if len(sections) > 1 && !allowed[urn] {
+			urns = append(urns, urn)
+		}
+	}
+
+	if len(urns) == 0 {
+		return
+	}
+
+	sort.Strings(urns)
+
+	var clashes []string
+	for _, urn := range urns {
+		clashes = append(clashes, fmt.Sprintf("%s is used in %s", urn, strings.Join(urnSections[urn], ", ")))
+	}
+
+	err = fmt.Errorf("urns are used in incompatible sections of config %s: %s", p.configFile, strings.Join(clashes, "; "))
+
+	return
+}
+
+// urnSections returns the sections each urn is used in
+func (p *SpiritHelper) urnSections() (urnSections map[string][]string) {
+	urnSections = map[string][]string{}
+
+	for _, section := range p.actorSections() {
+		for _, actor := range section.Actors {
+			exist := false
+			for _, name := range urnSections[actor.URN] {
+				if name == section.Name {
+					exist = true
+					break
+				}
+			}
+
+			if !exist {
+				urnSections[actor.URN] = append(urnSections[actor.URN], section.Name)
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	ErrVendorWithCheckedOut = errors.New("use checked out packages is not supported in vendor mode, the packages are fetched by go modules")
+	ErrVendorWithSince      = errors.New("update since is not supported in vendor mode, the packages are fetched by go modules")
+	ErrVendorWithPrune      = errors.New("prune packages is not supported in vendor mode, the packages are not fetched into GOPATH")
+	ErrVendorWithGoPathMode = errors.New("build profile with GO111MODULE=off is not supported in vendor mode, the project is a module")
+)
+
+// validateVendor returns the error of the GOPATH mode options in vendor mode
+func (p *CreateOptions) validateVendor() (err error) {
+	if !p.Vendor {
+		return
+	}
+
+	switch {
+	case p.UseCheckedOut:
+		return ErrVendorWithCheckedOut
+	case !p.UpdateSince.IsZero():
+		return ErrVendorWithSince
+	case p.PrunePackages:
+		return ErrVendorWithPrune
+	}
+
+	if profile, exist := p.BuildProfiles[p.SelectedProfile]; exist {
+		for _, env := range profile.Env {
+			if strings.TrimSpace(env) == "GO111MODULE=off" {
+				return ErrVendorWithGoPathMode
+			}
+		}
+	}
+
+	return
+}
+
+// vendorPackages makes the project a module and fetches RefPackages into
+// its vendor dir by `go mod vendor`, so the project is self-contained and
+// GOPATH is untouched, the revisions of createOpts are already applied to
+// RefPackages by CreateProject
+func (p *SpiritHelper) vendorPackages(createOpts CreateOptions) (err error) {
+	projectPath := createOpts.projectDir()
+
+	envs := []string{"GO111MODULE=on", "GOFLAGS=-mod=mod"}
+	if proxy := createOpts.goProxy(); proxy != "" {
+		envs = append(envs, "GOPROXY="+proxy)
+	}
+
+	var out []byte
+
+	if _, e := os.Stat(path.Join(projectPath, "go.mod")); os.IsNotExist(e) {
+		module := createOpts.ProjectPath
+		if path.IsAbs(module) {
+			module = path.Base(module)
+		}
+
+		if out, err = execCommandArgs(projectPath, envs, "go", "mod", "init", module); err != nil {
+			p.logger().Errorf("%s", out)
+			return
+		}
+	}
+
+	for _, pkg := range p.RefPackages {
+		version := "latest"
+		if pkg.Revision != "" {
+			version = pkg.Revision
+		}
+
+		p.logger().Infof("vendor package %s@%s", pkg.URI, version)
+
+		if out, err = execCommandArgs(projectPath, append(envs, createOpts.packageEnvs(pkg.URI)...), "go", "get", pkg.URI+"@"+version); err != nil {
+			p.logger().Errorf("%s", out)
+			return
+		}
+	}
+
+	for _, args := range [][]string{{"mod", "tidy"}, {"mod", "vendor"}} {
+		if out, err = execCommandArgs(projectPath, envs, "go", args...); err != nil {
+			p.logger().Errorf("%s", out)
+			return
+		}
+	}
+
+	return
+}